@@ -0,0 +1,195 @@
+// Package charset converts SGF files between the character set declared with CA and UTF-8.
+//
+// UTF-8, ISO-8859-1 (the FF[4] default), US-ASCII, GB2312, Shift_JIS and EUC-KR are built in. Other character sets
+// can be registered with Register. With golang.org/x/text this is a short adapter:
+//
+//	type textCharset struct{ encoding.Encoding }
+//
+//	func (c textCharset) NewDecoder(r io.Reader) io.Reader { return c.Encoding.NewDecoder().Reader(r) }
+//	func (c textCharset) NewEncoder(w io.Writer) io.Writer { return c.Encoding.NewEncoder().Writer(w) }
+//
+//	charset.Register("Shift_JIS", textCharset{japanese.ShiftJIS})
+package charset
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var UnknownCharsetError = errors.New("Unknown character set")
+
+// Charset converts text between a character set and UTF-8
+type Charset interface {
+	// NewDecoder returns a reader, which converts the text read from r to UTF-8
+	NewDecoder(r io.Reader) io.Reader
+	// NewEncoder returns a writer, which converts UTF-8 text to the character set and writes it to w. If the returned
+	// writer is an io.Closer, it must be closed to flush the remaining output.
+	NewEncoder(w io.Writer) io.Writer
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]Charset{}
+)
+
+func init() {
+	Register("UTF-8", utf8Charset{})
+	Register("UTF8", utf8Charset{})
+	Register("ISO-8859-1", latin1Charset{max: 0xff})
+	Register("Latin-1", latin1Charset{max: 0xff})
+	Register("Latin1", latin1Charset{max: 0xff})
+	Register("US-ASCII", latin1Charset{max: 0x7f})
+	Register("ASCII", latin1Charset{max: 0x7f})
+	Register("GB2312", gb2312)
+	Register("EUC-CN", gb2312)
+	Register("EUC-KR", eucKR)
+	Register("Shift_JIS", shiftJIS)
+	Register("SJIS", shiftJIS)
+	Register("MS_Kanji", shiftJIS)
+}
+
+// normalizeName makes the charset names case insensitive and ignores separators, so that e.g. "Shift_JIS",
+// "shift-jis" and "SHIFTJIS" are the same
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(name)))
+}
+
+// Register makes the charset available under the given name. Names are case insensitive and "-", "_" and spaces in
+// them are ignored. Registering an existing name replaces the charset.
+func Register(name string, charset Charset) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	registry[normalizeName(name)] = charset
+}
+
+// Lookup returns the charset registered under the name
+func Lookup(name string) (Charset, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	charset, ok := registry[normalizeName(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", UnknownCharsetError, name)
+	}
+	return charset, nil
+}
+
+// IsUTF8 reports whether the name refers to UTF-8, i.e. no conversion is needed. An empty name is considered UTF-8.
+func IsUTF8(name string) bool {
+	normalized := normalizeName(name)
+	return normalized == "" || normalized == "UTF8"
+}
+
+type utf8Charset struct{}
+
+func (utf8Charset) NewDecoder(r io.Reader) io.Reader {
+	return r
+}
+
+func (utf8Charset) NewEncoder(w io.Writer) io.Writer {
+	return w
+}
+
+// latin1Charset handles single byte charsets, which are a subset of unicode (ISO-8859-1 and US-ASCII).
+// Runes above max are encoded as "?".
+type latin1Charset struct {
+	max rune
+}
+
+func (c latin1Charset) NewDecoder(r io.Reader) io.Reader {
+	return newDecoder(r, func(reader *bufio.Reader) (rune, error) {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if rune(b) > c.max {
+			return utf8.RuneError, nil
+		}
+		return rune(b), nil
+	})
+}
+
+func (c latin1Charset) NewEncoder(w io.Writer) io.Writer {
+	return newEncoder(w, func(output []byte, r rune) []byte {
+		if r > c.max {
+			r = '?'
+		}
+		return append(output, byte(r))
+	})
+}
+
+// decoder converts the input to UTF-8 one character at a time. decode reads the next character and returns it as a
+// rune, utf8.RuneError if it is invalid.
+type decoder struct {
+	reader  *bufio.Reader
+	decode  func(reader *bufio.Reader) (rune, error)
+	pending []byte
+	err     error
+}
+
+func newDecoder(r io.Reader, decode func(reader *bufio.Reader) (rune, error)) *decoder {
+	return &decoder{reader: bufio.NewReader(r), decode: decode}
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	// decode what's available without blocking once there is some output
+	for len(d.pending) < len(p) && d.err == nil && (len(d.pending) == 0 || d.reader.Buffered() > 0) {
+		r, err := d.decode(d.reader)
+		if err != nil {
+			d.err = err
+			break
+		}
+		d.pending = utf8.AppendRune(d.pending, r)
+	}
+	if len(d.pending) == 0 {
+		return 0, d.err
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// encoder converts UTF-8 text to a charset one rune at a time. encode appends the encoded rune to the output.
+type encoder struct {
+	writer io.Writer
+	encode func(output []byte, r rune) []byte
+	// partial holds the start of a rune split between two writes
+	partial []byte
+}
+
+func newEncoder(w io.Writer, encode func(output []byte, r rune) []byte) *encoder {
+	return &encoder{writer: w, encode: encode}
+}
+
+func (e *encoder) Write(p []byte) (int, error) {
+	data := append(e.partial, p...)
+	e.partial = nil
+
+	output := make([]byte, 0, len(data))
+	for len(data) > 0 {
+		if !utf8.FullRune(data) {
+			e.partial = append([]byte(nil), data...)
+			break
+		}
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		output = e.encode(output, r)
+	}
+
+	if _, err := e.writer.Write(output); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
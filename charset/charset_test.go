@@ -0,0 +1,161 @@
+package charset_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/makpoc/sgfparser/charset"
+)
+
+func TestLookup(t *testing.T) {
+	type lookupStruct struct {
+		name string
+		err  error
+	}
+
+	var lookupMatrix = []lookupStruct{
+		{"UTF-8", nil},
+		{"utf8", nil},
+		{"iso-8859-1", nil},
+		{"ISO_8859_1", nil},
+		{" Latin-1 ", nil},
+		{"us-ascii", nil},
+		{"Shift_JIS", nil},
+		{"shift-jis", nil},
+		{"GB2312", nil},
+		{"euc-kr", nil},
+		{"Big5", charset.UnknownCharsetError},
+		{"", charset.UnknownCharsetError},
+	}
+
+	for i, current := range lookupMatrix {
+		if _, err := charset.Lookup(current.name); !errors.Is(err, current.err) {
+			t.Errorf("Test %d: expected error %v for %q, found %v", i, current.err, current.name, err)
+		}
+	}
+}
+
+func TestLatin1(t *testing.T) {
+	cs, err := charset.Lookup("ISO-8859-1")
+	if err != nil {
+		t.Fatalf("Lookup returned error! %s", err.Error())
+	}
+
+	decoded, err := io.ReadAll(cs.NewDecoder(strings.NewReader("caf\xe9 \xbd")))
+	if err != nil || string(decoded) != "café ½" {
+		t.Errorf("Expected %q, found %q (%v)", "café ½", decoded, err)
+	}
+
+	// write the runes split between several writes
+	var output bytes.Buffer
+	encoder := cs.NewEncoder(&output)
+	text := []byte("café ½ 世")
+	for i := range text {
+		if _, err := encoder.Write(text[i : i+1]); err != nil {
+			t.Fatalf("Write returned error! %s", err.Error())
+		}
+	}
+	if output.String() != "caf\xe9 \xbd ?" {
+		t.Errorf("Expected %q, found %q", "caf\xe9 \xbd ?", output.String())
+	}
+}
+
+func TestDoubleByte(t *testing.T) {
+	type doubleByteStruct struct {
+		charset string
+		encoded string
+		decoded string
+	}
+
+	var doubleByteMatrix = []doubleByteStruct{
+		{"GB2312", "C[\xce\xa7\xc6\xe5 \xba\xda\xcf\xc8]", "C[围棋 黑先]"},
+		{"EUC-KR", "C[\xb9\xd9\xb5\xcf \xc8\xe6]", "C[바둑 흑]"},
+		// the second byte of 表 is a backslash
+		{"Shift_JIS", "C[\x88\xcd\x8c\xe9 \x8d\x95\x94\xd4 \x95\\ \xba\xde]", "C[囲碁 黒番 表 ｺﾞ]"},
+	}
+
+	for i, current := range doubleByteMatrix {
+		cs, err := charset.Lookup(current.charset)
+		if err != nil {
+			t.Fatalf("Test %d: Lookup returned error! %s", i, err.Error())
+		}
+
+		// read one byte at a time, so that the characters are split between reads
+		decoded, err := io.ReadAll(cs.NewDecoder(iotest.OneByteReader(strings.NewReader(current.encoded))))
+		if err != nil || string(decoded) != current.decoded {
+			t.Errorf("Test %d: expected %q, found %q (%v)", i, current.decoded, decoded, err)
+		}
+
+		var output bytes.Buffer
+		encoder := cs.NewEncoder(&output)
+		text := []byte(current.decoded)
+		for j := range text {
+			if _, err := encoder.Write(text[j : j+1]); err != nil {
+				t.Fatalf("Test %d: Write returned error! %s", i, err.Error())
+			}
+		}
+		if output.String() != current.encoded {
+			t.Errorf("Test %d: expected %q, found %q", i, current.encoded, output.String())
+		}
+	}
+}
+
+func TestDoubleByteInvalid(t *testing.T) {
+	type invalidStruct struct {
+		charset string
+		encoded string
+		decoded string
+	}
+
+	var invalidMatrix = []invalidStruct{
+		{"GB2312", "a\xb0", "a\ufffd"},
+		{"GB2312", "\xb0ab", "\ufffdab"},
+		{"GB2312", "\xaa\xa1", "\ufffd"},
+		{"EUC-KR", "\x80a", "\ufffda"},
+		{"Shift_JIS", "\x88 a\x81", "\ufffd a\ufffd"},
+		{"Shift_JIS", "\xf0\x40", "\ufffd@"},
+	}
+
+	for i, current := range invalidMatrix {
+		cs, _ := charset.Lookup(current.charset)
+		decoded, err := io.ReadAll(cs.NewDecoder(strings.NewReader(current.encoded)))
+		if err != nil || string(decoded) != current.decoded {
+			t.Errorf("Test %d: expected %q, found %q (%v)", i, current.decoded, decoded, err)
+		}
+	}
+
+	cs, _ := charset.Lookup("EUC-KR")
+	var output bytes.Buffer
+	cs.NewEncoder(&output).Write([]byte("흑 é"))
+	if output.String() != "\xc8\xe6 ?" {
+		t.Errorf("Expected runes missing in EUC-KR to be encoded as '?', found %q", output.String())
+	}
+}
+
+func TestRegister(t *testing.T) {
+	charset.Register("x-test", upperCharset{})
+
+	cs, err := charset.Lookup("X_TEST")
+	if err != nil {
+		t.Fatalf("Lookup returned error! %s", err.Error())
+	}
+	decoded, _ := io.ReadAll(cs.NewDecoder(strings.NewReader("abc")))
+	if string(decoded) != "ABC" {
+		t.Errorf("Expected the registered charset, found %q", decoded)
+	}
+}
+
+type upperCharset struct{}
+
+func (upperCharset) NewDecoder(r io.Reader) io.Reader {
+	data, _ := io.ReadAll(r)
+	return bytes.NewReader(bytes.ToUpper(data))
+}
+
+func (upperCharset) NewEncoder(w io.Writer) io.Writer {
+	return w
+}
@@ -0,0 +1,163 @@
+package charset
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"unicode/utf8"
+)
+
+//go:generate go run gen.go
+
+// The double byte charsets below encode ASCII as single bytes and the characters of a 94x94 table (see tables.go)
+// as two bytes. Invalid input is decoded as utf8.RuneError, runes missing in the table are encoded as "?".
+
+var (
+	gb2312   = &eucCharset{table: &doubleByteTable{codes: &gb2312Table}}
+	eucKR    = &eucCharset{table: &doubleByteTable{codes: &ksx1001Table}}
+	shiftJIS = &shiftJISCharset{table: &doubleByteTable{codes: &jis0208Table}}
+)
+
+// doubleByteTable maps the characters of a 94x94 table to unicode and back
+type doubleByteTable struct {
+	codes *[94 * 94]uint16
+	// reverse maps the runes to their index in codes. It's built on the first use.
+	once    sync.Once
+	reverse map[rune]int
+}
+
+// rune returns the character at the row and cell (both 0-based)
+func (t *doubleByteTable) rune(row, cell int) rune {
+	if code := t.codes[row*94+cell]; code != 0 {
+		return rune(code)
+	}
+	return utf8.RuneError
+}
+
+// index returns the row and the cell (both 0-based) of the rune
+func (t *doubleByteTable) index(r rune) (int, int, bool) {
+	t.once.Do(func() {
+		t.reverse = make(map[rune]int)
+		for i, code := range t.codes {
+			if _, found := t.reverse[rune(code)]; code != 0 && !found {
+				t.reverse[rune(code)] = i
+			}
+		}
+	})
+
+	i, ok := t.reverse[r]
+	return i / 94, i % 94, ok
+}
+
+// eucCharset is an EUC encoding of a 94x94 table (GB2312 and EUC-KR): both bytes are 0xA1-0xFE
+type eucCharset struct {
+	table *doubleByteTable
+}
+
+func (c *eucCharset) NewDecoder(r io.Reader) io.Reader {
+	return newDecoder(r, func(reader *bufio.Reader) (rune, error) {
+		lead, err := reader.ReadByte()
+		if err != nil || lead < utf8.RuneSelf {
+			return rune(lead), err
+		}
+		if lead < 0xa1 || lead == 0xff {
+			return utf8.RuneError, nil
+		}
+
+		trail, err := reader.ReadByte()
+		if err != nil {
+			return utf8.RuneError, nil
+		}
+		if trail < 0xa1 || trail == 0xff {
+			// the trail byte may start the next character
+			reader.UnreadByte()
+			return utf8.RuneError, nil
+		}
+		return c.table.rune(int(lead-0xa1), int(trail-0xa1)), nil
+	})
+}
+
+func (c *eucCharset) NewEncoder(w io.Writer) io.Writer {
+	return newEncoder(w, func(output []byte, r rune) []byte {
+		if r < utf8.RuneSelf {
+			return append(output, byte(r))
+		}
+		row, cell, ok := c.table.index(r)
+		if !ok {
+			return append(output, '?')
+		}
+		return append(output, byte(row+0xa1), byte(cell+0xa1))
+	})
+}
+
+// shiftJISCharset is Shift_JIS: ASCII, half-width katakana as single bytes 0xA1-0xDF and JIS X 0208 as two bytes,
+// each lead byte (0x81-0x9F, 0xE0-0xEF) covering two rows
+type shiftJISCharset struct {
+	table *doubleByteTable
+}
+
+func (c *shiftJISCharset) NewDecoder(r io.Reader) io.Reader {
+	return newDecoder(r, func(reader *bufio.Reader) (rune, error) {
+		lead, err := reader.ReadByte()
+		if err != nil || lead < utf8.RuneSelf {
+			return rune(lead), err
+		}
+
+		var row int
+		switch {
+		case lead >= 0xa1 && lead <= 0xdf:
+			return 0xff61 + rune(lead-0xa1), nil
+		case lead >= 0x81 && lead <= 0x9f:
+			row = int(lead-0x81) * 2
+		case lead >= 0xe0 && lead <= 0xef:
+			row = int(lead-0xc1) * 2
+		default:
+			return utf8.RuneError, nil
+		}
+
+		trail, err := reader.ReadByte()
+		if err != nil {
+			return utf8.RuneError, nil
+		}
+		var cell int
+		switch {
+		case trail >= 0x40 && trail <= 0x7e:
+			cell = int(trail - 0x40)
+		case trail >= 0x80 && trail <= 0xfc:
+			cell = int(trail - 0x41)
+		default:
+			// the trail byte may start the next character
+			reader.UnreadByte()
+			return utf8.RuneError, nil
+		}
+		if cell >= 94 {
+			row, cell = row+1, cell-94
+		}
+		return c.table.rune(row, cell), nil
+	})
+}
+
+func (c *shiftJISCharset) NewEncoder(w io.Writer) io.Writer {
+	return newEncoder(w, func(output []byte, r rune) []byte {
+		switch {
+		case r < utf8.RuneSelf:
+			return append(output, byte(r))
+		case r >= 0xff61 && r <= 0xff9f:
+			return append(output, byte(r-0xff61+0xa1))
+		}
+
+		row, cell, ok := c.table.index(r)
+		if !ok {
+			return append(output, '?')
+		}
+		lead := byte(row/2 + 0x81)
+		if lead > 0x9f {
+			lead += 0x40
+		}
+		trail := byte(row%2*94 + cell + 0x40)
+		if trail >= 0x7f {
+			trail++
+		}
+		return append(output, lead, trail)
+	})
+}
@@ -0,0 +1,99 @@
+//go:build ignore
+
+// gen generates tables.go from mapping files of the 94x94 character sets in the unicode.org format: every line holds
+// the row/cell code (0x2121-0x7E7E) and the unicode code point as hex numbers, comments start with "#". Further
+// columns before them (e.g. the Shift_JIS code in JIS0208.TXT) are ignored.
+//
+//	go run gen.go -gb2312 GB2312.TXT -jis0208 JIS0208.TXT -ksx1001 KSX1001.TXT
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	gb2312 := flag.String("gb2312", "GB2312.TXT", "GB 2312 mapping file")
+	jis0208 := flag.String("jis0208", "JIS0208.TXT", "JIS X 0208 mapping file")
+	ksx1001 := flag.String("ksx1001", "KSX1001.TXT", "KS X 1001 mapping file")
+	output := flag.String("o", "tables.go", "output file")
+	flag.Parse()
+
+	var source bytes.Buffer
+	source.WriteString("// Code generated by go run gen.go; DO NOT EDIT.\n\npackage charset\n")
+	for _, table := range []struct{ name, title, path string }{
+		{"gb2312Table", "GB 2312", *gb2312},
+		{"jis0208Table", "JIS X 0208", *jis0208},
+		{"ksx1001Table", "KS X 1001", *ksx1001},
+	} {
+		codes, err := readMapping(table.path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		writeTable(&source, table.name, table.title, codes)
+	}
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, formatted, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readMapping returns the code points indexed by (row-1)*94 + (cell-1)
+func readMapping(path string) (*[94 * 94]uint16, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	codes := new([94 * 94]uint16)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected a code and a code point", path, line)
+		}
+
+		code, err := strconv.ParseUint(fields[len(fields)-2], 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		codePoint, err := strconv.ParseUint(fields[len(fields)-1], 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		row, cell := int(code>>8)-0x21, int(code&0xff)-0x21
+		if row < 0 || row >= 94 || cell < 0 || cell >= 94 {
+			return nil, fmt.Errorf("%s:%d: code %#04x out of range", path, line, code)
+		}
+		codes[row*94+cell] = uint16(codePoint)
+	}
+	return codes, scanner.Err()
+}
+
+func writeTable(source *bytes.Buffer, name, title string, codes *[94 * 94]uint16) {
+	fmt.Fprintf(source, "\n// %s maps the %s characters to unicode. The index is (row-1)*94 + (cell-1), 0 marks unused codes.\n", name, title)
+	fmt.Fprintf(source, "var %s = [94 * 94]uint16{\n", name)
+	for i, codePoint := range codes {
+		fmt.Fprintf(source, "0x%04x,", codePoint)
+		if i%16 == 15 {
+			source.WriteString("\n")
+		}
+	}
+	source.WriteString("\n}\n")
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/makpoc/sgfparser/structures"
+	"github.com/makpoc/sgfparser/writer"
+)
+
+// convertCommand converts an SGF file to JSON or a JSON file (see structures.Collection.MarshalJSON) back to SGF.
+// The input format is taken from the file extension.
+func convertCommand(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	to := flags.String("to", "json", "output format: json or sgf")
+	output := flags.String("o", "", "output file (default standard output)")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		printUsage()
+	}
+
+	collection, err := readCollection(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Println(err.Error())
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch *to {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(collection)
+	case "sgf":
+		err = writer.Encode(out, collection, writer.DefaultOptions)
+	default:
+		err = fmt.Errorf("unknown output format %q", *to)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+	return 0
+}
+
+// readCollection parses an SGF file or decodes a JSON file ending in ".json"
+func readCollection(path string) (*structures.Collection, error) {
+	if !strings.HasSuffix(strings.ToLower(path), ".json") {
+		return parseFile(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	collection := new(structures.Collection)
+	if err := json.Unmarshal(data, collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
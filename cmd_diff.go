@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/makpoc/sgfparser/diff"
+)
+
+// diffCommand prints the changes between two game records. The exit code is 1 if they differ.
+func diffCommand(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the changes as JSON operations")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		printUsage()
+	}
+
+	a, err := readCollection(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err.Error())
+		return 2
+	}
+	b, err := readCollection(flags.Arg(1))
+	if err != nil {
+		fmt.Println(err.Error())
+		return 2
+	}
+
+	ops := diff.Diff(a, b)
+	if *asJSON {
+		if ops == nil {
+			ops = []diff.Op{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(ops); err != nil {
+			fmt.Println(err.Error())
+			return 2
+		}
+	} else {
+		for _, op := range ops {
+			fmt.Println(op)
+		}
+	}
+
+	if len(ops) > 0 {
+		return 1
+	}
+	return 0
+}
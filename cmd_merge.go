@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/makpoc/sgfparser/merge"
+	"github.com/makpoc/sgfparser/structures"
+	"github.com/makpoc/sgfparser/writer"
+)
+
+// mergeCommand merges all game trees of the files into a single game tree and writes it as SGF
+func mergeCommand(args []string) int {
+	flags := flag.NewFlagSet("merge", flag.ContinueOnError)
+	output := flags.String("o", "", "output file (default standard output)")
+	comments := flags.String("comments", "first", "conflicting comments: first, last, join or fail")
+	root := flags.String("root", "first", "conflicting root properties: first, last, join or fail")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		printUsage()
+	}
+
+	var options merge.Options
+	var err error
+	if options.Comments, err = merge.ParseResolution(*comments); err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+	if options.Root, err = merge.ParseResolution(*root); err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+
+	merged := &structures.Collection{}
+	var trees []*structures.GameTree
+	for i, path := range flags.Args() {
+		collection, err := readCollection(path)
+		if err != nil {
+			fmt.Println(err.Error())
+			return 1
+		}
+		if i == 0 {
+			merged.Charset = collection.Charset
+		}
+		trees = append(trees, collection.GameTrees...)
+	}
+
+	tree, err := merge.Merge(trees, options)
+	if err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+	merged.GameTrees = []*structures.GameTree{tree}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Println(err.Error())
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := writer.Encode(out, merged, writer.DefaultOptions); err != nil {
+		fmt.Println(err.Error())
+		return 1
+	}
+	return 0
+}
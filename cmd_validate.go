@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/makpoc/sgfparser/parser"
+	"github.com/makpoc/sgfparser/validate"
+)
+
+// fileFindings are the findings of a single file in the JSON output
+type fileFindings struct {
+	File     string             `json:"file"`
+	Error    string             `json:"error,omitempty"`
+	Findings []validate.Finding `json:"findings"`
+}
+
+// validateCommand checks the files and prints the findings. The exit code is 1 if any file has errors.
+func validateCommand(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the findings as JSON")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		printUsage()
+	}
+	return validateFiles(os.Stdout, flags.Args(), *asJSON)
+}
+
+// validateFiles checks the files and writes the findings to out. Game trees, which fail to parse, are reported as
+// findings as well. It returns the exit code.
+func validateFiles(out io.Writer, paths []string, asJSON bool) int {
+	exitCode := 0
+	var results []fileFindings
+
+	for _, path := range paths {
+		result := fileFindings{File: path, Findings: []validate.Finding{}}
+
+		collection, diagnostics, err := parser.ParseFile(path, parser.DefaultOptions)
+		if err != nil {
+			result.Error = err.Error()
+			exitCode = 1
+		} else {
+			result.Findings = append(result.Findings, validate.ValidateParsed(collection, diagnostics)...)
+			if validate.HasErrors(result.Findings) {
+				exitCode = 1
+			}
+		}
+		results = append(results, result)
+
+		if asJSON {
+			continue
+		}
+		if result.Error != "" {
+			fmt.Fprintf(out, "%s: %s\n", path, result.Error)
+		}
+		for _, finding := range result.Findings {
+			fmt.Fprintf(out, "%s: %s\n", path, finding)
+		}
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			fmt.Fprintln(out, err.Error())
+			return 1
+		}
+	}
+	return exitCode
+}
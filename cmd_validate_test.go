@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/makpoc/sgfparser/validate"
+)
+
+func TestValidateFiles(t *testing.T) {
+	type validateStruct struct {
+		input    string
+		exitCode int
+		// rule and path of the expected findings
+		rules []validate.Rule
+		paths []string
+	}
+
+	var validateMatrix = []validateStruct{
+		{"(;FF[4]GM[1];B[aa])", 0, nil, nil},
+		{"(;FF[4]GM[1];B[aa])(;FF[4]GM[1];Bx[aa])", 1, []validate.Rule{validate.Syntax}, []string{"1.1"}},
+		{"(;FF[4]GM[1];B[aa]B[bb])", 1, []validate.Rule{validate.DuplicateProperty}, []string{"0.1"}},
+		{"(;FF[4]GM[1];B[aa](;W[bb])(;W[cc];B[dd]W[ee]W[ff]))", 1, []validate.Rule{validate.DuplicateProperty}, []string{"0.1-1.2"}},
+		// the skipped tree doesn't change the indexes of the trees after it
+		{"(;FF[4]GM[1];B[aa];Bx[bb])(;FF[4]GM[1]SZ[3];B[dd])", 1, []validate.Rule{validate.Syntax, validate.OutOfBoard}, []string{"0.2", "1.1"}},
+		{"(;FF[4]GM[1])(;Bx[aa])(;FF[4]GM[1]SZ[3];B[dd])(;FF[4]GM[1]SZ[3];W[dd])", 1,
+			[]validate.Rule{validate.Syntax, validate.OutOfBoard, validate.OutOfBoard}, []string{"1.0", "2.1", "3.1"}},
+	}
+
+	for i, current := range validateMatrix {
+		path := filepath.Join(t.TempDir(), "game.sgf")
+		if err := os.WriteFile(path, []byte(current.input), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		var output bytes.Buffer
+		if exitCode := validateFiles(&output, []string{path}, true); exitCode != current.exitCode {
+			t.Errorf("Test %d: expected exit code %d, found %d", i, current.exitCode, exitCode)
+		}
+
+		// Severity is written as text and can't be read back, so decode the fields by hand
+		var results []struct {
+			Findings []struct {
+				Severity string
+				Rule     validate.Rule
+				Path     string
+			}
+		}
+		if err := json.Unmarshal(output.Bytes(), &results); err != nil {
+			t.Fatalf("Test %d: Unmarshal returned error! %s", i, err.Error())
+		}
+		if len(results) != 1 || len(results[0].Findings) != len(current.rules) {
+			t.Errorf("Test %d: expected %d findings, found %s", i, len(current.rules), output.String())
+			continue
+		}
+		for j, finding := range results[0].Findings {
+			if finding.Severity != validate.Error.String() || finding.Rule != current.rules[j] || finding.Path != current.paths[j] {
+				t.Errorf("Test %d: expected an error %s at %s, found %v", i, current.rules[j], current.paths[j], finding)
+			}
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Package diff compares two versions of a game record. The game trees are aligned by their moves rather than by
+// their text, so reordered variations and changed annotations are reported as such.
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/makpoc/sgfparser/structures"
+)
+
+// OpType is the kind of change
+type OpType string
+
+const (
+	AddGameTree     OpType = "add-game-tree"
+	RemoveGameTree  OpType = "remove-game-tree"
+	AddVariation    OpType = "add-variation"
+	RemoveVariation OpType = "remove-variation"
+	MoveVariation   OpType = "move-variation"
+	AddProperty     OpType = "add-property"
+	RemoveProperty  OpType = "remove-property"
+	ChangeProperty  OpType = "change-property"
+	MoveProperty    OpType = "move-property"
+)
+
+// Op is a single change, which turns the first collection into the second. All paths (see structures.FormatPath) refer
+// to the nodes of the first collection.
+type Op struct {
+	Op OpType `json:"op"`
+	// Path is the node the change applies to. For AddVariation it's the node the variation is added after, for
+	// RemoveVariation and MoveVariation the first node of the variation. It's empty for game tree changes.
+	Path string `json:"path,omitempty"`
+	// To is the node a property is moved to (MoveProperty)
+	To string `json:"to,omitempty"`
+	// Index is the game tree (AddGameTree, RemoveGameTree) or the new position of the variation among its
+	// siblings (AddVariation, MoveVariation)
+	Index    *int                 `json:"index,omitempty"`
+	Property structures.PropIdent `json:"property,omitempty"`
+	// From holds the old values and Value the new ones
+	From  []structures.PropValue `json:"from,omitempty"`
+	Value []structures.PropValue `json:"value,omitempty"`
+	// SGF is the added or removed game tree or variation
+	SGF string `json:"sgf,omitempty"`
+}
+
+func (op Op) String() string {
+	switch op.Op {
+	case AddGameTree:
+		return fmt.Sprintf("game tree %d added: %s", *op.Index, op.SGF)
+	case RemoveGameTree:
+		return fmt.Sprintf("game tree %d removed: %s", *op.Index, op.SGF)
+	case AddVariation:
+		return fmt.Sprintf("%s: variation %d added: %s", op.Path, *op.Index, op.SGF)
+	case RemoveVariation:
+		return fmt.Sprintf("%s: variation removed: %s", op.Path, op.SGF)
+	case MoveVariation:
+		return fmt.Sprintf("%s: variation moved to position %d", op.Path, *op.Index)
+	case AddProperty:
+		return fmt.Sprintf("%s: %s added: %s", op.Path, op.Property, values(op.Value))
+	case RemoveProperty:
+		return fmt.Sprintf("%s: %s removed: %s", op.Path, op.Property, values(op.From))
+	case ChangeProperty:
+		return fmt.Sprintf("%s: %s changed: %s -> %s", op.Path, op.Property, values(op.From), values(op.Value))
+	case MoveProperty:
+		return fmt.Sprintf("%s: %s moved to %s: %s", op.Path, op.Property, op.To, values(op.Value))
+	}
+	return fmt.Sprintf("%s: %s", op.Path, op.Op)
+}
+
+func values(values []structures.PropValue) string {
+	var output strings.Builder
+	for _, value := range values {
+		fmt.Fprintf(&output, "[%s]", value.Escape(false))
+	}
+	return output.String()
+}
+
+// Diff compares the game trees of both collections pairwise and returns the changes in the order of the nodes
+func Diff(a, b *structures.Collection) []Op {
+	var ops []Op
+	for i := 0; i < max(len(a.GameTrees), len(b.GameTrees)); i++ {
+		switch {
+		case i >= len(b.GameTrees):
+			ops = append(ops, Op{Op: RemoveGameTree, Index: index(i), SGF: a.GameTrees[i].String()})
+		case i >= len(a.GameTrees):
+			ops = append(ops, Op{Op: AddGameTree, Index: index(i), SGF: b.GameTrees[i].String()})
+		default:
+			ops = append(ops, DiffGameTree(i, a.GameTrees[i], b.GameTrees[i])...)
+		}
+	}
+	return ops
+}
+
+// DiffGameTree compares two versions of a game tree. The root nodes are always compared with each other. Below them
+// the children of compared nodes are paired by their move (B or W): variations found only in one tree are reported
+// as added or removed, variations found in both are compared recursively. Nodes without a move are paired in order.
+// index is the index of the tree in its collection and is used in the paths.
+func DiffGameTree(index int, a, b *structures.GameTree) []Op {
+	d := &differ{tree: index}
+	rootA, rootB := structures.NewCursor(a), structures.NewCursor(b)
+	if rootA == nil || rootB == nil {
+		if a.String() != b.String() {
+			return []Op{{Op: RemoveGameTree, Index: &index, SGF: a.String()}, {Op: AddGameTree, Index: &index, SGF: b.String()}}
+		}
+		return nil
+	}
+
+	d.compare(*rootA, *rootB, nil)
+	return d.movedProperties()
+}
+
+func index(i int) *int {
+	return &i
+}
+
+type differ struct {
+	tree int
+	ops  []Op
+}
+
+// compare compares the nodes of a and b and their children. steps is the path of the node in a.
+func (d *differ) compare(a, b structures.Cursor, steps []int) {
+	path := structures.FormatPath(d.tree, steps)
+	d.compareProperties(path, *a.Node(), *b.Node())
+
+	childrenA, childrenB := children(a), children(b)
+	matched := make([]bool, len(childrenB))
+	// pairs holds the index of the paired child in b for each child in a, -1 if it was removed
+	pairs := make([]int, len(childrenA))
+
+	for i, childA := range childrenA {
+		pairs[i] = -1
+		for j, childB := range childrenB {
+			if !matched[j] && childA.Node().Move() == childB.Node().Move() {
+				matched[j], pairs[i] = true, j
+				break
+			}
+		}
+	}
+
+	// the order of the paired children in b, so that added and removed variations do not count as moves
+	var orderB []int
+	for _, j := range pairs {
+		if j >= 0 {
+			orderB = append(orderB, j)
+		}
+	}
+	slices.Sort(orderB)
+
+	rank := 0
+	for i, childA := range childrenA {
+		childSteps := append(slices.Clone(steps), i)
+		if pairs[i] < 0 {
+			d.ops = append(d.ops, Op{Op: RemoveVariation, Path: structures.FormatPath(d.tree, childSteps), SGF: subtree(childA)})
+			continue
+		}
+		moved := orderB[rank] != pairs[i]
+		rank++
+		if moved {
+			d.ops = append(d.ops, Op{Op: MoveVariation, Path: structures.FormatPath(d.tree, childSteps), Index: index(pairs[i])})
+		}
+		d.compare(childA, childrenB[pairs[i]], childSteps)
+	}
+
+	for j, childB := range childrenB {
+		if !matched[j] {
+			d.ops = append(d.ops, Op{Op: AddVariation, Path: path, Index: index(j), SGF: subtree(childB)})
+		}
+	}
+}
+
+// compareProperties compares the properties of two paired nodes regardless of their order
+func (d *differ) compareProperties(path string, a, b structures.Node) {
+	options := structures.EqualOptions{IgnoreValueOrder: true}
+
+	for _, propA := range a.Properties {
+		propB, found := b.Property(propA.Ident)
+		switch {
+		case !found:
+			d.ops = append(d.ops, Op{Op: RemoveProperty, Path: path, Property: propA.Ident, From: propA.Values})
+		case !propA.Equal(*propB, options):
+			d.ops = append(d.ops, Op{Op: ChangeProperty, Path: path, Property: propA.Ident, From: propA.Values, Value: propB.Values})
+		}
+	}
+	for _, propB := range b.Properties {
+		if _, found := a.Property(propB.Ident); !found {
+			d.ops = append(d.ops, Op{Op: AddProperty, Path: path, Property: propB.Ident, Value: propB.Values})
+		}
+	}
+}
+
+// movedProperties replaces pairs of a removed and an added property with the same values by a single MoveProperty
+func (d *differ) movedProperties() []Op {
+	options := structures.EqualOptions{IgnoreValueOrder: true}
+	// pair the removed properties with the added ones first, as the added one may come before the removed one
+	targets := map[int]int{}
+	used := make([]bool, len(d.ops))
+	for i, removed := range d.ops {
+		if removed.Op != RemoveProperty {
+			continue
+		}
+		from := structures.Property{Ident: removed.Property, Values: removed.From}
+		for j, added := range d.ops {
+			if used[j] || added.Op != AddProperty || added.Property != removed.Property {
+				continue
+			}
+			if from.Equal(structures.Property{Ident: added.Property, Values: added.Value}, options) {
+				used[j] = true
+				targets[i] = j
+				break
+			}
+		}
+	}
+
+	var ops []Op
+	for i, op := range d.ops {
+		if used[i] {
+			continue
+		}
+		if j, found := targets[i]; found {
+			op = Op{Op: MoveProperty, Path: op.Path, To: d.ops[j].Path, Property: op.Property, Value: op.From}
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+func children(c structures.Cursor) []structures.Cursor {
+	var children []structures.Cursor
+	for i := 0; i < c.Variations(); i++ {
+		child := c
+		child.Next(i)
+		children = append(children, child)
+	}
+	return children
+}
+
+// subtree returns the SGF text of the variation starting at the node of the cursor
+func subtree(c structures.Cursor) string {
+	var output strings.Builder
+	output.WriteString(string(structures.GameTreeStart))
+	for {
+		output.WriteString(c.Node().String())
+		if c.Variations() != 1 {
+			break
+		}
+		c.Next(0)
+	}
+	for _, child := range children(c) {
+		output.WriteString(subtree(child))
+	}
+	output.WriteString(string(structures.GameTreeEnd))
+	return output.String()
+}
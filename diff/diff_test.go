@@ -0,0 +1,82 @@
+package diff_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/makpoc/sgfparser/diff"
+	"github.com/makpoc/sgfparser/internal/sgftest"
+)
+
+func TestDiff(t *testing.T) {
+	type diffStruct struct {
+		a   string
+		b   string
+		ops []string
+	}
+
+	var diffMatrix = []diffStruct{
+		{"(;C[a];B[aa];W[bb])", "(;C[a];B[aa];W[bb])", nil},
+		{"(;C[a];B[aa];W[bb])", "(;B[aa];C[a];W[bb])", []string{
+			"0.0: C removed: [a]",
+			"0.0: B added: [aa]",
+			"0.1: variation removed: (;B[aa];W[bb])",
+			"0.0: variation 0 added: (;C[a];W[bb])",
+		}},
+		{"(;C[a];B[aa]C[x]AB[cc][dd];W[bb])", "(;C[b];B[aa]AB[dd][cc]GN[g];W[bb])", []string{
+			"0.0: C changed: [a] -> [b]",
+			"0.1: C removed: [x]",
+			"0.1: GN added: [g]",
+		}},
+		{"(;C[a];B[aa]TR[cc];W[bb])", "(;C[a];B[aa];W[bb]TR[cc])", []string{
+			"0.1: TR moved to 0.2: [cc]",
+		}},
+		{"(;FF[4];B[aa];W[bb]TR[cc])", "(;FF[4];B[aa]TR[cc];W[bb])", []string{
+			"0.2: TR moved to 0.1: [cc]",
+		}},
+		{"(;C[a](;B[aa])(;B[bb])(;B[cc]))", "(;C[a](;B[cc])(;B[aa])(;B[dd]))", []string{
+			"0.1: variation moved to position 1",
+			"0.0-1.1: variation removed: (;B[bb])",
+			"0.0-2.1: variation moved to position 0",
+			"0.0: variation 2 added: (;B[dd])",
+		}},
+		{"(;C[a](;B[aa])(;B[bb]))", "(;C[a](;B[aa];W[cc])(;B[bb]))", []string{
+			"0.1: variation 0 added: (;W[cc])",
+		}},
+		{"(;C[a];B[aa];W[cc](;B[dd])(;B[ee]))", "(;C[a];B[aa];W[cc];B[dd])", []string{
+			"0.2-1.1: variation removed: (;B[ee])",
+		}},
+		{"(;C[a])(;C[b])", "(;C[a])", []string{
+			"game tree 1 removed: (;C[b])",
+		}},
+	}
+
+	for i, current := range diffMatrix {
+		ops := diff.Diff(sgftest.ParseCollection(t, current.a), sgftest.ParseCollection(t, current.b))
+		if len(ops) != len(current.ops) {
+			t.Errorf("Test %d: expected %d changes, found %v", i, len(current.ops), ops)
+			continue
+		}
+		for j, op := range ops {
+			if op.String() != current.ops[j] {
+				t.Errorf("Test %d: expected %q, found %q", i, current.ops[j], op.String())
+			}
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	ops := diff.Diff(sgftest.ParseCollection(t, "(;C[a](;B[aa]C[x])(;B[bb]))"), sgftest.ParseCollection(t, "(;C[a](;B[bb])(;B[aa]C[y])(;B[cc])))"))
+
+	output, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatalf("Marshal returned error! %s", err.Error())
+	}
+	expected := `[{"op":"move-variation","path":"0.1","index":1},` +
+		`{"op":"change-property","path":"0.1","property":"C","from":["x"],"value":["y"]},` +
+		`{"op":"move-variation","path":"0.0-1.1","index":0},` +
+		`{"op":"add-variation","path":"0.0","index":2,"sgf":"(;B[cc])"}]`
+	if string(output) != expected {
+		t.Errorf("Expected %s, found %s", expected, output)
+	}
+}
@@ -0,0 +1,168 @@
+// Package goboard replays go games onto a board.
+package goboard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/makpoc/sgfparser/structures"
+)
+
+// MaxSize is the largest board size, which can be encoded in SGF
+const MaxSize = 52
+
+var OutOfBoardError = errors.New("Point is outside the board")
+var OccupiedError = errors.New("Point is occupied")
+var SuicideError = errors.New("Suicide")
+var KoError = errors.New("Ko")
+
+// Empty is the color of an empty point
+const Empty structures.Color = 0
+
+// Board is a go board with stones on it. The zero value is not usable - create boards with NewBoard.
+type Board struct {
+	Width  int
+	Height int
+	points []structures.Color
+}
+
+// NewBoard creates an empty board with the given size
+func NewBoard(width, height int) (*Board, error) {
+	if width < 1 || height < 1 || width > MaxSize || height > MaxSize {
+		return nil, fmt.Errorf("invalid board size %dx%d", width, height)
+	}
+	return &Board{Width: width, Height: height, points: make([]structures.Color, width*height)}, nil
+}
+
+// Contains reports whether the point is on the board
+func (b *Board) Contains(p structures.Point) bool {
+	return p.X >= 0 && p.Y >= 0 && p.X < b.Width && p.Y < b.Height
+}
+
+// At returns the color of the stone at the point or Empty
+func (b *Board) At(p structures.Point) structures.Color {
+	if !b.Contains(p) {
+		return Empty
+	}
+	return b.points[p.Y*b.Width+p.X]
+}
+
+// Set puts a stone of the given color (or Empty) on the point without checking any rules
+func (b *Board) Set(p structures.Point, color structures.Color) error {
+	if !b.Contains(p) {
+		return fmt.Errorf("%w: %s", OutOfBoardError, p)
+	}
+	b.points[p.Y*b.Width+p.X] = color
+	return nil
+}
+
+// Clone returns an independent copy of the board
+func (b *Board) Clone() *Board {
+	clone := *b
+	clone.points = append([]structures.Color(nil), b.points...)
+	return &clone
+}
+
+func (b *Board) neighbours(p structures.Point) []structures.Point {
+	var result []structures.Point
+	for _, n := range []structures.Point{{X: p.X - 1, Y: p.Y}, {X: p.X + 1, Y: p.Y}, {X: p.X, Y: p.Y - 1}, {X: p.X, Y: p.Y + 1}} {
+		if b.Contains(n) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// Group returns the stones connected to the stone at p and the number of their liberties
+func (b *Board) Group(p structures.Point) ([]structures.Point, int) {
+	color := b.At(p)
+	if color == Empty {
+		return nil, 0
+	}
+
+	var stones []structures.Point
+	visited := map[structures.Point]bool{p: true}
+	liberties := map[structures.Point]bool{}
+	queue := []structures.Point{p}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		stones = append(stones, current)
+
+		for _, n := range b.neighbours(current) {
+			switch b.At(n) {
+			case Empty:
+				liberties[n] = true
+			case color:
+				if !visited[n] {
+					visited[n] = true
+					queue = append(queue, n)
+				}
+			}
+		}
+	}
+	return stones, len(liberties)
+}
+
+// Play puts a stone of the given color on the point and removes the captured opponent stones, which are returned.
+// Occupied points and suicide are rejected and the board is left unchanged. If allowSuicide is true, a suicide move
+// removes the own group instead and the removed stones are returned.
+func (b *Board) Play(p structures.Point, color structures.Color, allowSuicide bool) ([]structures.Point, error) {
+	if !b.Contains(p) {
+		return nil, fmt.Errorf("%w: %s", OutOfBoardError, p)
+	}
+	if b.At(p) != Empty {
+		return nil, fmt.Errorf("%w: %s", OccupiedError, p)
+	}
+
+	b.Set(p, color)
+
+	var captured []structures.Point
+	for _, n := range b.neighbours(p) {
+		if b.At(n) != color.Opponent() {
+			continue
+		}
+		if stones, liberties := b.Group(n); liberties == 0 {
+			b.remove(stones)
+			captured = append(captured, stones...)
+		}
+	}
+
+	if stones, liberties := b.Group(p); liberties == 0 {
+		if !allowSuicide {
+			b.Set(p, Empty)
+			return nil, fmt.Errorf("%w: %s %s", SuicideError, color, p)
+		}
+		b.remove(stones)
+		return stones, nil
+	}
+
+	return captured, nil
+}
+
+func (b *Board) remove(stones []structures.Point) {
+	for _, stone := range stones {
+		b.Set(stone, Empty)
+	}
+}
+
+// String draws the board with X for black, O for white and . for empty points
+func (b *Board) String() string {
+	var output strings.Builder
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			switch b.At(structures.Point{X: x, Y: y}) {
+			case structures.Black:
+				output.WriteRune('X')
+			case structures.White:
+				output.WriteRune('O')
+			default:
+				output.WriteRune('.')
+			}
+		}
+		output.WriteRune('\n')
+	}
+	return output.String()
+}
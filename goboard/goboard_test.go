@@ -0,0 +1,118 @@
+package goboard_test
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/makpoc/sgfparser/goboard"
+	"github.com/makpoc/sgfparser/internal/sgftest"
+	"github.com/makpoc/sgfparser/parser"
+	"github.com/makpoc/sgfparser/structures"
+)
+
+func board(rows ...string) string {
+	return strings.Join(rows, "\n") + "\n"
+}
+
+func TestReplay(t *testing.T) {
+	type replayStruct struct {
+		raw      string
+		board    string
+		toPlay   structures.Color
+		captures int
+	}
+
+	var replayMatrix = []replayStruct{
+		// capture in the corner
+		{"(;SZ[3];B[ba];W[aa];B[ab])", board(".X.", "X..", "..."), structures.White, 1},
+		// setup, compressed point lists and PL
+		{"(;SZ[3]AB[aa:ab]AW[cc]PL[W])", board("X..", "X..", "..O"), structures.White, 0},
+		{"(;SZ[3]AB[aa:cc];AE[bb:cc])", board("XXX", "X..", "X.."), structures.Black, 0},
+		// passes
+		{"(;SZ[19];B[];W[tt];B[aa])", "", structures.White, 0},
+		// rectangular board
+		{"(;SZ[4:2];B[da];W[db])", board("...X", "...O"), structures.Black, 0},
+	}
+
+	for i, current := range replayMatrix {
+		pos, err := goboard.Replay(sgftest.ParseGameTree(t, current.raw).MainLine())
+		if err != nil {
+			t.Errorf("Test %d returned error! %s", i, err.Error())
+			continue
+		}
+		if current.board != "" && pos.Board.String() != current.board {
+			t.Errorf("Test %d: expected board\n%s\nfound\n%s", i, current.board, pos.Board)
+		}
+		if pos.ToPlay != current.toPlay {
+			t.Errorf("Test %d: expected %s to play, found %s", i, current.toPlay, pos.ToPlay)
+		}
+		if captures := pos.Captures[structures.Black] + pos.Captures[structures.White]; captures != current.captures {
+			t.Errorf("Test %d: expected %d captures, found %d", i, current.captures, captures)
+		}
+	}
+}
+
+func TestReplayNeg(t *testing.T) {
+	type replayStruct struct {
+		raw string
+		err error
+	}
+
+	var replayMatrix = []replayStruct{
+		{"(;SZ[3];B[aa];W[aa])", goboard.OccupiedError},
+		{"(;SZ[3]AW[ba][ab];B[aa])", goboard.SuicideError},
+		{"(;SZ[3];B[dd])", goboard.OutOfBoardError},
+		// B captures at ba, W may not retake immediately
+		{"(;SZ[4]AB[aa][ca]AW[ab][cb][bc][ba];B[bb];W[ba])", goboard.KoError},
+		{"(;SZ[4]AB[aa][ca]AW[ab][cb][bc][ba];B[bb];W[dd];B[dc];W[ba])", nil},
+	}
+
+	for i, current := range replayMatrix {
+		_, err := goboard.Replay(sgftest.ParseGameTree(t, current.raw).MainLine())
+		if current.err == nil {
+			if err != nil {
+				t.Errorf("Test %d returned error! %s", i, err.Error())
+			}
+			continue
+		}
+		if !errors.Is(err, current.err) {
+			t.Errorf("Test %d: expected %v, found %v", i, current.err, err)
+		}
+	}
+}
+
+func TestPositionAt(t *testing.T) {
+	raw, err := os.ReadFile("../files/sample_big.sgf")
+	if err != nil {
+		t.Fatalf("Failed to read sample: %s", err.Error())
+	}
+	collection, err := parser.ParseCollection(bufio.NewReader(strings.NewReader(string(raw))))
+	if err != nil {
+		t.Fatalf("Failed to parse sample: %s", err.Error())
+	}
+
+	// the setup variation: compressed point lists, AE and PL
+	cursor := structures.NewCursor(collection.GameTrees[0])
+	cursor.Next(1)
+	cursor.ToEnd()
+
+	pos, err := goboard.PositionAt(cursor)
+	if err != nil {
+		t.Fatalf("Failed to replay: %s", err.Error())
+	}
+	if pos.ToPlay != structures.White {
+		t.Errorf("Expected white to play, found %s", pos.ToPlay)
+	}
+	for _, check := range []struct {
+		point string
+		color structures.Color
+	}{{"dd", structures.Black}, {"do", structures.Black}, {"ep", goboard.Empty}, {"fq", structures.Black}, {"jd", structures.White}, {"pd", structures.Black}, {"pp", structures.White}, {"pn", goboard.Empty}} {
+		point, _ := structures.ParsePoint(structures.PropValue(check.point))
+		if color := pos.Board.At(point); color != check.color {
+			t.Errorf("Expected %q at %s, found %q", check.color, check.point, color)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package goboard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/makpoc/sgfparser/structures"
+)
+
+// DefaultSize is the board size used when the root node has no SZ property
+const DefaultSize = 19
+
+// Position is the state of a game after a node was replayed
+type Position struct {
+	Board *Board
+	// ToPlay is the color of the player to move next
+	ToPlay structures.Color
+	// Captures holds the number of stones captured by each color
+	Captures map[structures.Color]int
+	// Ko is the point the opponent of the last capturing player may not play at because of a simple ko, or nil
+	Ko      *structures.Point
+	koColor structures.Color
+	// MoveNumber is the number of moves played so far (or set with MN)
+	MoveNumber int
+	// AllowSuicide makes suicide moves legal. The own group is removed and counted as captured by the opponent.
+	AllowSuicide bool
+}
+
+// NewPosition creates the empty starting position for a board of the given size with black to play
+func NewPosition(width, height int) (*Position, error) {
+	board, err := NewBoard(width, height)
+	if err != nil {
+		return nil, err
+	}
+	return &Position{Board: board, ToPlay: structures.Black, Captures: map[structures.Color]int{}}, nil
+}
+
+// NewPositionForRoot creates the starting position for a game with the given root node. The board size is read from SZ.
+func NewPositionForRoot(root structures.Node) (*Position, error) {
+	width, height, err := BoardSize(root)
+	if err != nil {
+		return nil, err
+	}
+	return NewPosition(width, height)
+}
+
+// BoardSize returns the board size declared with SZ in the root node (e.g. SZ[19] or SZ[19:13]).
+func BoardSize(root structures.Node) (int, int, error) {
+	for _, prop := range root.Properties {
+		if prop.Ident != "SZ" {
+			continue
+		}
+
+		value, err := prop.AsText()
+		if err != nil {
+			return 0, 0, err
+		}
+		widthText, heightText, found := strings.Cut(value, ":")
+		if !found {
+			heightText = widthText
+		}
+
+		width, errWidth := strconv.Atoi(widthText)
+		height, errHeight := strconv.Atoi(heightText)
+		if errWidth != nil || errHeight != nil {
+			return 0, 0, &structures.ValueError{Ident: prop.Ident, Value: structures.PropValue(value), Type: structures.NumberValue, Msg: "invalid board size"}
+		}
+		return width, height, nil
+	}
+	return DefaultSize, DefaultSize, nil
+}
+
+// Clone returns an independent copy of the position
+func (pos *Position) Clone() *Position {
+	clone := *pos
+	clone.Board = pos.Board.Clone()
+	clone.Captures = map[structures.Color]int{}
+	for color, count := range pos.Captures {
+		clone.Captures[color] = count
+	}
+	if pos.Ko != nil {
+		ko := *pos.Ko
+		clone.Ko = &ko
+	}
+	return &clone
+}
+
+// IsPass reports whether the move value is a pass: an empty value or "tt" on boards up to 19x19
+func (pos *Position) IsPass(value structures.PropValue) bool {
+	return value == "" || value == "tt" && pos.Board.Width <= 19 && pos.Board.Height <= 19
+}
+
+// Apply executes the setup (AB, AW, AE, PL) and move (B, W, MN) properties of the node. Setup is applied before the
+// move. Illegal moves are reported as errors and leave the position unchanged.
+func (pos *Position) Apply(node structures.Node) error {
+	next := pos.Clone()
+
+	for _, prop := range node.Properties {
+		var err error
+		switch prop.Ident {
+		case "AB":
+			err = next.setup(prop, structures.Black)
+		case "AW":
+			err = next.setup(prop, structures.White)
+		case "AE":
+			err = next.setup(prop, Empty)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, prop := range node.Properties {
+		var err error
+		switch prop.Ident {
+		case "PL":
+			next.ToPlay, err = prop.AsColor()
+		case "B":
+			err = next.play(prop, structures.Black)
+		case "W":
+			err = next.play(prop, structures.White)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// MN overrides the counted move number
+	for _, prop := range node.Properties {
+		if prop.Ident == "MN" {
+			number, err := prop.AsNumber()
+			if err != nil {
+				return err
+			}
+			next.MoveNumber = number
+		}
+	}
+
+	*pos = *next
+	return nil
+}
+
+func (pos *Position) setup(prop structures.Property, color structures.Color) error {
+	points, err := prop.AsPoints()
+	if err != nil {
+		return err
+	}
+	for _, point := range points {
+		if err := pos.Board.Set(point, color); err != nil {
+			return err
+		}
+	}
+	pos.Ko = nil
+	return nil
+}
+
+func (pos *Position) play(prop structures.Property, color structures.Color) error {
+	value, err := prop.AsText()
+	if err != nil {
+		return err
+	}
+
+	pos.MoveNumber++
+	pos.ToPlay = color.Opponent()
+
+	if pos.IsPass(structures.PropValue(value)) {
+		pos.Ko = nil
+		return nil
+	}
+
+	point, err := prop.AsPoint()
+	if err != nil {
+		return err
+	}
+	if pos.Ko != nil && *pos.Ko == point && pos.koColor == color {
+		return fmt.Errorf("%w: %s %s", KoError, color, point)
+	}
+
+	removed, err := pos.Board.Play(point, color, pos.AllowSuicide)
+	if err != nil {
+		return err
+	}
+
+	if pos.Board.At(point) == Empty {
+		// suicide - the own stones were removed
+		pos.Captures[color.Opponent()] += len(removed)
+		pos.Ko = nil
+		return nil
+	}
+	pos.Captures[color] += len(removed)
+
+	// simple ko: a single stone captured a single stone and is left in atari
+	pos.Ko = nil
+	if len(removed) == 1 {
+		if stones, liberties := pos.Board.Group(point); len(stones) == 1 && liberties == 1 {
+			ko := removed[0]
+			pos.Ko, pos.koColor = &ko, color.Opponent()
+		}
+	}
+	return nil
+}
+
+// Replay replays the nodes, starting with the root node, and returns the final position
+func Replay(nodes []structures.Node) (*Position, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes to replay")
+	}
+
+	pos, err := NewPositionForRoot(nodes[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for i, node := range nodes {
+		if err := pos.Apply(node); err != nil {
+			return nil, fmt.Errorf("node %d: %w", i, err)
+		}
+	}
+	return pos, nil
+}
+
+// PositionAt replays the game from the root to the node the cursor points to
+func PositionAt(cursor *structures.Cursor) (*Position, error) {
+	path := cursor.Path()
+
+	current := *cursor
+	current.ToRoot()
+	nodes := []structures.Node{*current.Node()}
+	for _, variation := range path {
+		current.Next(variation)
+		nodes = append(nodes, *current.Node())
+	}
+
+	return Replay(nodes)
+}
@@ -0,0 +1,31 @@
+// Package sgftest holds the helpers shared by the tests of the other packages.
+package sgftest
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/makpoc/sgfparser/parser"
+	"github.com/makpoc/sgfparser/structures"
+)
+
+// ParseCollection parses the SGF text in FailFast mode and stops the test if it fails
+func ParseCollection(t testing.TB, raw string) *structures.Collection {
+	t.Helper()
+	collection, _, err := parser.ParseCollectionWithOptions(bufio.NewReader(strings.NewReader(raw)), parser.Options{Recovery: parser.FailFast})
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %s", raw, err.Error())
+	}
+	return collection
+}
+
+// ParseGameTree parses the SGF text of a single game tree and stops the test if it fails
+func ParseGameTree(t testing.TB, raw string) *structures.GameTree {
+	t.Helper()
+	gTree, err := parser.ParseGameTree(bufio.NewReader(strings.NewReader(raw)))
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %s", raw, err.Error())
+	}
+	return gTree
+}
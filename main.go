@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"strings"
@@ -11,8 +10,21 @@ import (
 	"github.com/makpoc/sgfparser/structures"
 )
 
+// commands maps the subcommand names to their implementations. They get the arguments after the name and return the
+// exit code.
+var commands = map[string]func(args []string) int{
+	"validate": validateCommand,
+	"convert":  convertCommand,
+	"diff":     diffCommand,
+	"merge":    mergeCommand,
+}
+
 func printUsage() {
-	fmt.Printf("Usage: %s file.sgf", os.Args[0])
+	fmt.Printf("Usage: %s file.sgf\n", os.Args[0])
+	fmt.Printf("       %s validate [-json] file.sgf...\n", os.Args[0])
+	fmt.Printf("       %s convert [-to json|sgf] [-o output] file.sgf|file.json\n", os.Args[0])
+	fmt.Printf("       %s diff [-json] a.sgf b.sgf\n", os.Args[0])
+	fmt.Printf("       %s merge [-o output] [-comments first|last|join|fail] [-root first|last|join|fail] file.sgf...\n", os.Args[0])
 	os.Exit(1)
 }
 
@@ -27,25 +39,26 @@ func dumpTree(gTree *structures.GameTree, identLevel int) {
 
 }
 
+// parseFile parses the file and logs the diagnostics
+func parseFile(path string) (*structures.Collection, error) {
+	collection, diagnostics, err := parser.ParseFile(path, parser.DefaultOptions)
+	for _, diagnostic := range diagnostics {
+		logger.LogWarn(diagnostic.String())
+	}
+	return collection, err
+}
+
 func main() {
 
 	if len(os.Args) < 2 {
 		printUsage()
 	}
 
-	file, err := os.Open(os.Args[1])
-	if err != nil {
-		logger.LogError("Failed to open file!")
-		os.Exit(1)
+	if command, ok := commands[os.Args[1]]; ok {
+		os.Exit(command(os.Args[2:]))
 	}
 
-	defer func() {
-		if err := file.Close(); err != nil {
-			logger.LogError("Failed to close file!")
-		}
-	}()
-
-	collection, err := parser.ParseCollection(bufio.NewReader(file))
+	collection, err := parseFile(os.Args[1])
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
@@ -0,0 +1,224 @@
+// Package merge combines several game records into a single game tree with variations.
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/makpoc/sgfparser/goboard"
+	"github.com/makpoc/sgfparser/structures"
+)
+
+var ConflictError = errors.New("Conflicting property values")
+var BoardSizeError = errors.New("Games with different board sizes can not be merged")
+
+// Resolution defines which value is kept when the merged trees have different values for the same property
+type Resolution int
+
+const (
+	// KeepFirst keeps the value of the tree merged first
+	KeepFirst Resolution = iota
+	// KeepLast keeps the value of the tree merged last
+	KeepLast
+	// JoinText joins different texts with an empty line. Values which are not texts are kept as with KeepFirst.
+	JoinText
+	// FailOnConflict stops the merge with a ConflictError
+	FailOnConflict
+)
+
+func (resolution Resolution) String() string {
+	switch resolution {
+	case KeepFirst:
+		return "first"
+	case KeepLast:
+		return "last"
+	case JoinText:
+		return "join"
+	case FailOnConflict:
+		return "fail"
+	}
+	return fmt.Sprintf("Resolution(%d)", int(resolution))
+}
+
+// ParseResolution returns the Resolution with the given name ("first", "last", "join" or "fail")
+func ParseResolution(name string) (Resolution, error) {
+	for _, resolution := range []Resolution{KeepFirst, KeepLast, JoinText, FailOnConflict} {
+		if resolution.String() == name {
+			return resolution, nil
+		}
+	}
+	return KeepFirst, fmt.Errorf("unknown conflict resolution %q", name)
+}
+
+// Options configure how conflicting values are resolved
+type Options struct {
+	// Comments is used for comments (C) in all nodes
+	Comments Resolution
+	// Root is used for the other properties of the root node (e.g. PB, RE, KM)
+	Root Resolution
+}
+
+// Merge combines the game trees into a new tree. Variations starting with the same move are merged, so common move
+// prefixes are shared and the trees branch where they diverge. The root nodes are always merged. Nodes without a move
+// are merged in order with other nodes without a move.
+//
+// Conflicting comments and root properties are resolved according to the options. Other properties missing in a
+// merged node are added, while conflicting ones keep the first value. The board size must be the same in all trees,
+// a missing SZ is 19x19. Errors point to the node with its index in trees and its path (see structures.FormatPath).
+// The given trees are not changed.
+func Merge(trees []*structures.GameTree, options Options) (*structures.GameTree, error) {
+	if len(trees) == 0 {
+		return nil, structures.EmptyGameTreeError
+	}
+
+	merged := trees[0].Clone()
+	root := structures.NewCursor(merged)
+	if root == nil {
+		return nil, structures.EmptyGameTreeError
+	}
+
+	m := &merger{options: options}
+	for i, tree := range trees[1:] {
+		other := structures.NewCursor(tree)
+		if other == nil {
+			continue
+		}
+		m.tree = i + 1
+		if err := m.merge(*root, *other, true); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+type merger struct {
+	options Options
+	// tree is the index of the tree being merged
+	tree int
+}
+
+// merge merges the node of other and its children into the node of target
+func (m *merger) merge(target, other structures.Cursor, isRoot bool) error {
+	if isRoot {
+		if err := compareBoardSizes(*target.Node(), *other.Node()); err != nil {
+			return err
+		}
+	}
+	if err := m.mergeProperties(target, other, isRoot); err != nil {
+		return err
+	}
+
+	for i := 0; i < other.Variations(); i++ {
+		otherChild := other
+		otherChild.Next(i)
+
+		targetChild, found := matchingChild(target, *otherChild.Node())
+		if !found {
+			if err := target.Tree().AttachVariation(target.Index(), subtree(otherChild)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := m.merge(targetChild, otherChild, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *merger) mergeProperties(target, other structures.Cursor, isRoot bool) error {
+	node := target.Node()
+
+	for _, prop := range other.Node().Properties {
+		existing, found := node.Property(prop.Ident)
+		if !found {
+			node.Properties = append(node.Properties, prop.Clone())
+			continue
+		}
+		if existing.Equal(prop, structures.EqualOptions{IgnoreWhitespace: true}) {
+			continue
+		}
+
+		resolution := KeepFirst
+		switch {
+		case prop.Ident == "C":
+			resolution = m.options.Comments
+		case isRoot && prop.Ident == "SZ":
+			// the sizes are the same (see compareBoardSizes), only written differently
+			continue
+		case isRoot:
+			resolution = m.options.Root
+		}
+
+		switch resolution {
+		case KeepLast:
+			*existing = prop.Clone()
+		case JoinText:
+			if info, ok := structures.LookupProperty(prop.Ident); ok && (info.Type == structures.TextValue || info.Type == structures.SimpleTextValue) {
+				*existing = joinText(*existing, prop)
+			}
+		case FailOnConflict:
+			return fmt.Errorf("%w: %s at %s", ConflictError, prop.Ident, structures.FormatPath(m.tree, other.Path()))
+		}
+	}
+	return nil
+}
+
+// compareBoardSizes returns a BoardSizeError if the root nodes declare different board sizes
+func compareBoardSizes(root, other structures.Node) error {
+	width, height, err := goboard.BoardSize(root)
+	if err != nil {
+		return err
+	}
+	otherWidth, otherHeight, err := goboard.BoardSize(other)
+	if err != nil {
+		return err
+	}
+	if width != otherWidth || height != otherHeight {
+		return fmt.Errorf("%w: %dx%d and %dx%d", BoardSizeError, width, height, otherWidth, otherHeight)
+	}
+	return nil
+}
+
+// joinText appends the text of other to the text of prop, unless it's already contained
+func joinText(prop, other structures.Property) structures.Property {
+	text, otherText := string(prop.Values[0]), string(other.Values[0])
+	if strings.Contains(text, otherText) {
+		return prop
+	}
+	return structures.Property{Ident: prop.Ident, Values: []structures.PropValue{structures.PropValue(text + "\n\n" + otherText)}}
+}
+
+// matchingChild returns the child of the target node with the same move (B or W) as the node
+func matchingChild(target structures.Cursor, node structures.Node) (structures.Cursor, bool) {
+	for i := 0; i < target.Variations(); i++ {
+		child := target
+		child.Next(i)
+		if child.Node().Move() == node.Move() {
+			return child, true
+		}
+	}
+	return structures.Cursor{}, false
+}
+
+// subtree copies the variation starting at the node of the cursor into a new game tree
+func subtree(c structures.Cursor) *structures.GameTree {
+	tree := &structures.GameTree{}
+	for {
+		tree.Sequence.Nodes = append(tree.Sequence.Nodes, c.Node().Clone())
+		if c.Variations() != 1 {
+			break
+		}
+		c.Next(0)
+	}
+
+	for i := 0; i < c.Variations(); i++ {
+		child := c
+		child.Next(i)
+		childTree := subtree(child)
+		childTree.Parent = tree
+		tree.Children = append(tree.Children, childTree)
+	}
+	return tree
+}
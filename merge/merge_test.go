@@ -0,0 +1,101 @@
+package merge_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/makpoc/sgfparser/internal/sgftest"
+	"github.com/makpoc/sgfparser/merge"
+	"github.com/makpoc/sgfparser/structures"
+)
+
+func TestMerge(t *testing.T) {
+	type mergeStruct struct {
+		input    string
+		options  merge.Options
+		expected string
+	}
+
+	var mergeMatrix = []mergeStruct{
+		{"(;SZ[19];B[aa];W[bb])", merge.Options{}, "(;SZ[19];B[aa];W[bb])"},
+		{"(;SZ[19];B[aa];W[bb];B[cc])(;SZ[19];B[aa];W[bb];B[dd])", merge.Options{},
+			"(;SZ[19];B[aa];W[bb](;B[cc])(;B[dd]))"},
+		{"(;SZ[19];B[aa];W[bb])(;SZ[19];B[aa];W[bb];B[cc];W[dd])", merge.Options{},
+			"(;SZ[19];B[aa];W[bb];B[cc];W[dd])"},
+		{"(;SZ[19];B[aa](;W[bb])(;W[cc]))(;SZ[19];B[aa];W[cc];B[dd])(;SZ[19];B[ee])", merge.Options{},
+			"(;SZ[19](;B[aa](;W[bb])(;W[cc];B[dd]))(;B[ee]))"},
+		{"(;FF[4];B[aa])(;FF[4]SZ[19];B[aa];W[bb])(;FF[4]SZ[19:19];B[aa])", merge.Options{Root: merge.FailOnConflict},
+			"(;FF[4]SZ[19];B[aa];W[bb])"},
+		{"(;PB[x]RE[B+R];B[aa]C[a])(;PB[y]KM[6.5];B[aa]C[b]TR[cc])", merge.Options{},
+			"(;PB[x]RE[B+R]KM[6.5];B[aa]C[a]TR[cc])"},
+		{"(;PB[x];B[aa]C[a])(;PB[y];B[aa]C[b])", merge.Options{Comments: merge.KeepLast, Root: merge.KeepLast},
+			"(;PB[y];B[aa]C[b])"},
+		{"(;PB[x];B[aa]C[a])(;PB[y];B[aa]C[b])(;PB[z];B[aa]C[a])", merge.Options{Comments: merge.JoinText, Root: merge.JoinText},
+			"(;PB[x\n\ny\n\nz];B[aa]C[a\n\nb])"},
+		{"(;KM[6.5]C[a])(;KM[7.5]C[ a ])", merge.Options{Comments: merge.FailOnConflict, Root: merge.JoinText},
+			"(;KM[6.5]C[a])"},
+		{"(;B[aa]C[x])(;B[aa]C[y])", merge.Options{Root: merge.FailOnConflict},
+			"(;B[aa]C[x])"},
+	}
+
+	for i, current := range mergeMatrix {
+		trees := sgftest.ParseCollection(t, current.input).GameTrees
+		input := sgftest.ParseCollection(t, current.input).GameTrees
+		merged, err := merge.Merge(trees, current.options)
+		if err != nil {
+			t.Errorf("Test %d: unexpected error: %s", i, err.Error())
+			continue
+		}
+		if merged.String() != current.expected {
+			t.Errorf("Test %d: expected %q, found %q", i, current.expected, merged.String())
+		}
+		for j, tree := range trees {
+			if !tree.Equal(input[j], structures.EqualOptions{}) {
+				t.Errorf("Test %d: game tree %d was changed to %s", i, j, tree.String())
+			}
+		}
+	}
+}
+
+func TestMergeNeg(t *testing.T) {
+	type mergeStruct struct {
+		input   string
+		options merge.Options
+		err     error
+	}
+
+	var mergeMatrix = []mergeStruct{
+		{"(;SZ[19];B[aa])(;SZ[13];B[aa])", merge.Options{}, merge.BoardSizeError},
+		// a missing SZ is 19x19
+		{"(;FF[4];B[aa])(;FF[4]SZ[9];B[aa])", merge.Options{}, merge.BoardSizeError},
+		{"(;FF[4]SZ[9:9];B[aa])(;FF[4];B[aa])", merge.Options{}, merge.BoardSizeError},
+		{"(;PB[x])(;PB[y])", merge.Options{Root: merge.FailOnConflict}, merge.ConflictError},
+		{"(;B[aa];W[bb]C[x])(;B[aa];W[bb]C[y])", merge.Options{Comments: merge.FailOnConflict}, merge.ConflictError},
+		{"", merge.Options{}, structures.EmptyGameTreeError},
+	}
+
+	for i, current := range mergeMatrix {
+		_, err := merge.Merge(sgftest.ParseCollection(t, current.input).GameTrees, current.options)
+		if !errors.Is(err, current.err) {
+			t.Errorf("Test %d: expected error %v, found %v", i, current.err, err)
+		}
+	}
+
+	_, err := merge.Merge(sgftest.ParseCollection(t, "(;B[aa];W[bb]C[x])(;B[aa];W[bb]C[y])").GameTrees, merge.Options{Comments: merge.FailOnConflict})
+	if err == nil || !strings.Contains(err.Error(), "C at 1.1") {
+		t.Errorf("Expected the conflict at 1.1, found %v", err)
+	}
+}
+
+func TestParseResolution(t *testing.T) {
+	for _, resolution := range []merge.Resolution{merge.KeepFirst, merge.KeepLast, merge.JoinText, merge.FailOnConflict} {
+		parsed, err := merge.ParseResolution(resolution.String())
+		if err != nil || parsed != resolution {
+			t.Errorf("Expected %s, found %s (%v)", resolution, parsed, err)
+		}
+	}
+	if _, err := merge.ParseResolution("second"); err == nil {
+		t.Errorf("Expected an error for an unknown resolution")
+	}
+}
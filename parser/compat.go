@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+
+	"github.com/makpoc/sgfparser/structures"
+)
+
+// renamedProperties maps FF[1]-FF[3] properties to their FF[4] equivalents
+var renamedProperties = map[structures.PropIdent]structures.PropIdent{
+	"L": "LB",
+	"M": "MA",
+}
+
+// obsoleteProperties were removed in FF[4] without a replacement. They are kept, as FF[4] requires for unknown
+// properties, and reported.
+var obsoleteProperties = map[structures.PropIdent]string{
+	"BS": "black species",
+	"CH": "check mark",
+	"EL": "evaluation of the computer move",
+	"EX": "expected move",
+	"ID": "game identifier",
+	"LT": "enforce losing on time",
+	"OM": "moves per overtime",
+	"OP": "length of the overtime",
+	"OV": "operator overhead",
+	"RG": "region",
+	"SC": "secure stones",
+	"SE": "self test moves",
+	"SI": "sigma",
+	"TC": "territory count",
+	"WS": "white species",
+}
+
+// convertOldIdent drops the lower case letters, which FF[1]-FF[3] allowed in property identifiers (e.g. "AddBlack"
+// becomes "AB" and "Black" becomes "B"). The conversion is reported for the position at.
+func convertOldIdent(pr *positionReader, ident structures.PropIdent, at position) structures.PropIdent {
+	var converted []rune
+	for _, r := range ident {
+		if !unicode.IsLower(r) {
+			converted = append(converted, r)
+		}
+	}
+
+	if len(converted) == len([]rune(ident)) {
+		return ident
+	}
+	pr.convert(at, fmt.Sprintf("%s converted to %s", ident, string(converted)))
+	return structures.PropIdent(converted)
+}
+
+// convertOldProperty replaces FF[1]-FF[3] properties with their FF[4] equivalents and reports obsolete ones.
+func convertOldProperty(pr *positionReader, prop *structures.Property, at position) {
+	if description, ok := obsoleteProperties[prop.Ident]; ok {
+		pr.convert(at, fmt.Sprintf("%s (%s) is obsolete in FF[4], kept unchanged", prop.Ident, description))
+		return
+	}
+
+	switch prop.Ident {
+	case "FF":
+		if len(prop.Values) == 1 {
+			if version, err := strconv.Atoi(string(prop.Values[0])); err == nil && version >= 1 && version <= 3 {
+				pr.convert(at, fmt.Sprintf("FF[%d] converted to FF[4]", version))
+				prop.Values = []structures.PropValue{"4"}
+				prop.Raw = []structures.PropValue{"4"}
+			}
+		}
+		return
+	case "L":
+		// the points were labeled with the letters a, b, c... in the order of the values
+		for i, value := range prop.Values {
+			label := structures.PropValue(fmt.Sprintf("%s:%c", value, 'a'+i%26))
+			prop.Values[i] = label
+			prop.Raw[i] = label
+		}
+	}
+
+	if renamed, ok := renamedProperties[prop.Ident]; ok {
+		pr.convert(at, fmt.Sprintf("%s converted to %s", prop.Ident, renamed))
+		prop.Ident = renamed
+	}
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"iter"
+	"strings"
+
+	"github.com/makpoc/sgfparser/structures"
+)
+
+// Decoder reads the top level game trees of a collection one at a time. Only the text of the current game tree is
+// kept in memory, so huge collections can be processed with memory bounded by the largest single game.
+type Decoder struct {
+	reader      *positionReader
+	options     Options
+	index       int
+	diagnostics []Diagnostic
+	err         error
+	// charset is the character set the input was converted from (see Open)
+	charset string
+	// nextID is the Id of the first node of the next game tree
+	nextID int
+}
+
+// NewDecoder creates a Decoder, which handles broken game trees according to options.Recovery
+func NewDecoder(reader io.RuneScanner, options Options) *Decoder {
+	return &Decoder{reader: track(reader), options: options, nextID: 1}
+}
+
+// Next parses and returns the next top level game tree. It returns io.EOF when there are no more game trees.
+// The nodes are numbered in pre-order across all returned game trees (see structures.Node.Id).
+// Broken game trees are skipped or repaired according to the options and listed in Diagnostics. In FailFast mode the
+// error of the first broken tree is returned and the decoder stops.
+func (d *Decoder) Next() (*structures.GameTree, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	for {
+		index := d.index
+		d.index++
+
+		// read the whole tree first, so that a broken tree can be skipped without losing track of the next one
+		raw, err := readGameTree(d.reader, d.options.Recovery == BestEffort)
+		if err == io.EOF {
+			d.err = io.EOF
+			return nil, d.err
+		}
+
+		var syntaxErr *SyntaxError
+		if err != nil && !errors.As(err, &syntaxErr) {
+			// not a syntax error - the reader itself failed
+			d.err = err
+			return nil, d.err
+		}
+
+		var gTree *structures.GameTree
+		var conversions []repair
+		if err == nil {
+			treeReader := trackFrom(strings.NewReader(raw.text), raw.start)
+			treeReader.values = d.options.Values
+			treeReader.compatibility = d.options.Compatibility
+			gTree, err = ParseGameTree(treeReader)
+			conversions = treeReader.conversions
+		}
+
+		if err != nil {
+			if d.options.Recovery == FailFast {
+				d.err = err
+				return nil, d.err
+			}
+
+			diagnostic := Diagnostic{TreeIndex: index, Action: TreeSkipped, Msg: err.Error(), Err: err}
+			if errors.As(err, &syntaxErr) {
+				diagnostic.Line, diagnostic.Column, diagnostic.Offset = syntaxErr.Line, syntaxErr.Column, syntaxErr.Offset
+				if raw != nil {
+					steps := nodeSteps(raw.text, int(syntaxErr.Offset-raw.start.Offset))
+					diagnostic.Path = structures.FormatPath(index, steps)
+				}
+			}
+			d.diagnostics = append(d.diagnostics, diagnostic)
+			continue
+		}
+
+		for _, r := range raw.repairs {
+			d.diagnostics = append(d.diagnostics, Diagnostic{TreeIndex: index, Action: TreeRepaired, Line: r.pos.Line, Column: r.pos.Column, Offset: r.pos.Offset, Msg: r.msg})
+		}
+		for _, c := range conversions {
+			d.diagnostics = append(d.diagnostics, Diagnostic{TreeIndex: index, Action: PropertyConverted, Line: c.pos.Line, Column: c.pos.Column, Offset: c.pos.Offset, Msg: c.msg})
+		}
+		d.nextID = gTree.AssignIDs(d.nextID)
+		return gTree, nil
+	}
+}
+
+// Charset returns the character set the input was converted from by Open. It's empty for UTF-8 input.
+func (d *Decoder) Charset() string {
+	return d.charset
+}
+
+// Diagnostics lists the game trees skipped or repaired so far
+func (d *Decoder) Diagnostics() []Diagnostic {
+	return d.diagnostics
+}
+
+// All returns an iterator over the remaining game trees. The iteration stops at the end of the input or after the
+// first error, which is yielded with a nil tree.
+func (d *Decoder) All() iter.Seq2[*structures.GameTree, error] {
+	return func(yield func(*structures.GameTree, error) bool) {
+		for {
+			gTree, err := d.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(gTree, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+var EmptyNodeError = errors.New("EmptyNode")
+var ElementEndError = errors.New("Element's end reached")
+var ParseError = errors.New("Parsing failed")
+var DuplicatePropertyError = errors.New("Duplicate property in node")
+
+// Element is the grammar element, which was being parsed when an error occurred
+type Element int
+
+const (
+	GameTreeElement Element = iota
+	SequenceElement
+	NodeElement
+	PropIdentElement
+	PropValueElement
+)
+
+func (element Element) String() string {
+	switch element {
+	case GameTreeElement:
+		return "GameTree"
+	case SequenceElement:
+		return "Sequence"
+	case NodeElement:
+		return "Node"
+	case PropIdentElement:
+		return "PropIdent"
+	case PropValueElement:
+		return "PropValue"
+	}
+	return fmt.Sprintf("Element(%d)", int(element))
+}
+
+// SyntaxError is returned by all Parse* functions. It describes where in the input the error was detected.
+//
+// Every SyntaxError matches ParseError with errors.Is. The more specific cause (e.g. EmptyNodeError,
+// DuplicatePropertyError or io.EOF) can be checked with errors.Is as well.
+type SyntaxError struct {
+	// Element is the grammar element being parsed
+	Element Element
+	// Line and Column are 1-based
+	Line   int
+	Column int
+	// Offset is the byte offset from the start of the input
+	Offset int64
+	// Rune is the offending rune. It is 0 if the end of the input was reached.
+	Rune rune
+	// Msg describes the problem
+	Msg string
+	// Err is the underlying cause, if any
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	output := fmt.Sprintf("%s at line %d, column %d (offset %d)", e.Element, e.Line, e.Column, e.Offset)
+	if e.Rune != 0 {
+		output += fmt.Sprintf(", near %q", e.Rune)
+	}
+	if e.Msg != "" {
+		output += ": " + e.Msg
+	}
+	if e.Err != nil && e.Err != ParseError {
+		output += ": " + e.Err.Error()
+	}
+	return output
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports every SyntaxError as a ParseError
+func (e *SyntaxError) Is(target error) bool {
+	return target == ParseError
+}
+
+// newSyntaxError creates a SyntaxError at the position of the last rune read from the reader.
+// If err is already a SyntaxError it is returned unchanged, so that the innermost element is reported.
+func newSyntaxError(reader *positionReader, element Element, err error, msg string) error {
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return err
+	}
+
+	return &SyntaxError{
+		Element: element,
+		Line:    reader.last.Line,
+		Column:  reader.last.Column,
+		Offset:  reader.last.Offset,
+		Rune:    reader.lastRune,
+		Msg:     msg,
+		Err:     err,
+	}
+}
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/makpoc/sgfparser/charset"
+	"github.com/makpoc/sgfparser/structures"
+)
+
+// sniffSize is the number of bytes searched for the CA property of the root node
+const sniffSize = 64 * 1024
+
+// defaultCharset is the FF[4] default for files without CA
+const defaultCharset = "ISO-8859-1"
+
+// Open creates a Decoder for SGF data in any registered character set (see the charset package). The character set is
+// taken from the CA property of the first root node and the input is converted to UTF-8. If there is no CA property,
+// options.FallbackCharset or, if it's empty, ISO-8859-1 (the FF[4] default) is used. An unknown character set is
+// reported as charset.UnknownCharsetError.
+//
+// The character set of the first game tree is used for the whole input.
+func Open(reader io.Reader, options Options) (*Decoder, error) {
+	buffered := bufio.NewReaderSize(reader, sniffSize)
+
+	// Peek returns what's available - a short input is not an error
+	head, err := buffered.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	name := sniffCharset(head)
+	if name == "" {
+		name = options.FallbackCharset
+	}
+	if name == "" {
+		name = defaultCharset
+	}
+
+	var decoded io.Reader = buffered
+	if !charset.IsUTF8(name) {
+		cs, err := charset.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		decoded = cs.NewDecoder(buffered)
+	} else {
+		name = ""
+	}
+
+	decoder := NewDecoder(bufio.NewReader(decoded), options)
+	decoder.charset = name
+	return decoder, nil
+}
+
+// ParseFile parses the SGF file at path, converting it from the character set declared with CA (see Open).
+// The character set is recorded in the Charset of the collection, so that the writer can convert it back.
+func ParseFile(path string, options Options) (*structures.Collection, []Diagnostic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	decoder, err := Open(file, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	collection := &structures.Collection{Charset: decoder.Charset()}
+	for {
+		gTree, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, decoder.Diagnostics(), err
+		}
+		collection.GameTrees = append(collection.GameTrees, gTree)
+	}
+
+	return collection, decoder.Diagnostics(), nil
+}
+
+// sniffCharset returns the first value of the CA property in the first node of the data or an empty string.
+// Only the ASCII characters of the SGF syntax are interpreted, so this works for all ASCII compatible charsets.
+func sniffCharset(data []byte) string {
+	start := bytes.IndexByte(data, byte(structures.GameTreeStart))
+	if start < 0 {
+		return ""
+	}
+	data = data[start+1:]
+
+	start = bytes.IndexByte(data, byte(structures.NodeSeparator))
+	if start < 0 {
+		return ""
+	}
+	data = data[start+1:]
+
+	var ident []byte
+	for len(data) > 0 {
+		switch b := data[0]; {
+		case b == byte(structures.NodeSeparator) || b == byte(structures.GameTreeStart) || b == byte(structures.GameTreeEnd):
+			// end of the root node
+			return ""
+		case b == byte(structures.PropertyValueStart):
+			value, rest, ok := readValue(data[1:])
+			if !ok {
+				return ""
+			}
+			if string(ident) == "CA" {
+				return string(value)
+			}
+			data = rest
+			// the next value belongs to the same property, unless a new identifier starts
+			if !startsValue(data) {
+				ident = ident[:0]
+			}
+			continue
+		case b >= 'A' && b <= 'Z':
+			ident = append(ident, b)
+		}
+		data = data[1:]
+	}
+	return ""
+}
+
+// readValue returns the text up to the first unescaped "]" and the data after it
+func readValue(data []byte) ([]byte, []byte, bool) {
+	doEscape := false
+	for i, b := range data {
+		switch {
+		case doEscape:
+			doEscape = false
+		case b == '\\':
+			doEscape = true
+		case b == byte(structures.PropertyValueEnd):
+			return data[:i], data[i+1:], true
+		}
+	}
+	return nil, nil, false
+}
+
+func startsValue(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == byte(structures.PropertyValueStart)
+}
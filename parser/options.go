@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// RecoveryMode defines what ParseCollectionWithOptions does with game trees, which fail to parse
+type RecoveryMode int
+
+const (
+	// SkipBadTree skips game trees, which fail to parse, and continues with the next one
+	SkipBadTree RecoveryMode = iota
+	// FailFast stops on the first game tree, which fails to parse, and returns its error
+	FailFast
+	// BestEffort tries to repair broken game trees (unterminated values and unbalanced parentheses at the end of the
+	// input) before parsing them. A value, which is still open at the end of the input, is closed at the first end of
+	// a game tree followed by another one inside it, so that the following trees are kept. Trees which still fail to
+	// parse are skipped.
+	BestEffort
+)
+
+func (mode RecoveryMode) String() string {
+	switch mode {
+	case SkipBadTree:
+		return "SkipBadTree"
+	case FailFast:
+		return "FailFast"
+	case BestEffort:
+		return "BestEffort"
+	}
+	return fmt.Sprintf("RecoveryMode(%d)", int(mode))
+}
+
+// ValueMode defines how the parser converts the text between the brackets to property values
+type ValueMode int
+
+const (
+	// NormalizedValues converts the values depending on the property type (see structures.NormalizeValueFor), e.g. line
+	// breaks in SimpleText become spaces, while Text keeps them
+	NormalizedValues ValueMode = iota
+	// RawValues keeps the values exactly as they were found between the brackets, including escape characters and
+	// soft line breaks. Useful for editors which must not change the user's formatting.
+	RawValues
+)
+
+// Options configure ParseCollectionWithOptions and Decoder
+type Options struct {
+	Recovery RecoveryMode
+	Values   ValueMode
+	// Compatibility accepts FF[1]-FF[3] files: lower case letters in property identifiers are dropped (e.g. "AddBlack"
+	// becomes "AB"), L and M are converted to LB and MA and FF[1]-FF[3] to FF[4]. The conversions and obsolete
+	// properties (e.g. ID, LT) are reported as PropertyConverted diagnostics.
+	Compatibility bool
+	// FallbackCharset is the character set used by Open and ParseFile when the root node has no CA property.
+	// Empty means ISO-8859-1, the FF[4] default. Use "UTF-8" for files written by programs, which omit CA for UTF-8.
+	FallbackCharset string
+}
+
+// DefaultOptions are the options used by ParseCollection
+var DefaultOptions = Options{Recovery: SkipBadTree}
+
+// Action is what the parser did with a broken or old game tree
+type Action int
+
+const (
+	// TreeSkipped - the game tree was left out of the collection
+	TreeSkipped Action = iota
+	// TreeRepaired - the game tree was repaired and added to the collection
+	TreeRepaired
+	// PropertyConverted - an FF[1]-FF[3] property was converted to FF[4] or reported as obsolete (see Options.Compatibility)
+	PropertyConverted
+)
+
+func (action Action) String() string {
+	switch action {
+	case TreeSkipped:
+		return "skipped"
+	case TreeRepaired:
+		return "repaired"
+	case PropertyConverted:
+		return "converted"
+	}
+	return fmt.Sprintf("Action(%d)", int(action))
+}
+
+// Diagnostic describes a game tree, which was skipped or repaired, or a property, which was converted while parsing a
+// collection
+type Diagnostic struct {
+	// TreeIndex is the index of the game tree in the input. Skipped trees are counted as well.
+	TreeIndex int
+	Action    Action
+	// Line, Column and Offset point to the place where the problem was detected
+	Line   int
+	Column int
+	Offset int64
+	// Path is the path of the node of a skipped tree, in which the problem was detected (see structures.FormatPath)
+	Path string
+	// Msg describes the problem
+	Msg string
+	// Err is the parse error for skipped trees
+	Err error
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("game tree %d %s at line %d, column %d (offset %d): %s", d.TreeIndex, d.Action, d.Line, d.Column, d.Offset, d.Msg)
+}
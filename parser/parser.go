@@ -15,40 +15,36 @@ const (
 	uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 )
 
-var EmptyNodeError = errors.New("EmptyNode")
-var ElementEndError = errors.New("Element's end reached")
-var ParseError = errors.New("Parsing failed")
-
+// ParseCollection parses all game trees in the reader. Game trees, which fail to parse, are skipped.
+// Use ParseCollectionWithOptions to choose another recovery mode or to get the list of skipped trees.
+// Collection = GameTree { GameTree }
 func ParseCollection(reader io.RuneScanner) (*structures.Collection, error) {
+	collection, diagnostics, err := ParseCollectionWithOptions(reader, DefaultOptions)
+	for _, diagnostic := range diagnostics {
+		logger.LogWarn(diagnostic.String())
+	}
+	return collection, err
+}
+
+// ParseCollectionWithOptions parses all game trees in the reader. Game trees which fail to parse are handled according
+// to options.Recovery and each skipped or repaired tree is listed in the returned diagnostics.
+// In FailFast mode the error of the first broken game tree is returned.
+func ParseCollectionWithOptions(reader io.RuneScanner, options Options) (*structures.Collection, []Diagnostic, error) {
 	collection := new(structures.Collection)
+	decoder := NewDecoder(reader, options)
 
 	for {
-		// check if we are at the end of the stream
-		_, _, err := reader.ReadRune()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return nil, err
-		}
-
-		// if the end is not reached - unread and let the gametree parser handle the next tree if any
-		err = reader.UnreadRune()
-		if err != nil {
-			return nil, err
+		gTree, err := decoder.Next()
+		if err == io.EOF {
+			break
 		}
-
-		gTree, err := ParseGameTree(reader)
-
 		if err != nil {
-			// maybe we should let this be configurable - fail the entire parsing process or just skip the current game tree
-			logger.LogWarn("Failed to parse game tree. Skipping it!")
-			continue
+			return nil, decoder.Diagnostics(), err
 		}
 		collection.GameTrees = append(collection.GameTrees, gTree)
 	}
 
-	return collection, nil
+	return collection, decoder.Diagnostics(), nil
 }
 
 // ParseGameTree parses a game tree. This function is recursive - if there are sub trees in
@@ -57,12 +53,13 @@ func ParseCollection(reader io.RuneScanner) (*structures.Collection, error) {
 // GameTree = "(" Sequence { GameTree } ")"
 func ParseGameTree(reader io.RuneScanner) (*structures.GameTree, error) {
 	gTree := new(structures.GameTree)
+	pr := track(reader)
 
 	// spin to the current tree start
 	for {
-		currRune, _, err := reader.ReadRune()
+		currRune, _, err := pr.ReadRune()
 		if err != nil {
-			return nil, err
+			return nil, newSyntaxError(pr, GameTreeElement, err, "could not find game tree start")
 		}
 		if currRune == structures.GameTreeStart {
 			break
@@ -70,16 +67,16 @@ func ParseGameTree(reader io.RuneScanner) (*structures.GameTree, error) {
 	}
 
 	// The sequence for the current tree
-	seq, err := ParseSequence(reader)
+	seq, err := ParseSequence(pr)
 	if err != nil {
-		return nil, err
+		return nil, newSyntaxError(pr, GameTreeElement, err, "")
 	}
 	gTree.Sequence = *seq
 
 	for {
-		currRune, _, err := reader.ReadRune()
+		currRune, _, err := pr.ReadRune()
 		if err != nil {
-			return nil, err
+			return nil, newSyntaxError(pr, GameTreeElement, err, "could not find game tree end")
 		}
 
 		if currRune == structures.GameTreeEnd {
@@ -88,12 +85,12 @@ func ParseGameTree(reader io.RuneScanner) (*structures.GameTree, error) {
 
 		if currRune == structures.GameTreeStart {
 			// subtree start
-			err = reader.UnreadRune()
+			err = pr.UnreadRune()
 			if err != nil {
-				return nil, err
+				return nil, newSyntaxError(pr, GameTreeElement, err, "")
 			}
 
-			subTree, err := ParseGameTree(reader)
+			subTree, err := ParseGameTree(pr)
 			if err != nil {
 				return nil, err
 			}
@@ -112,34 +109,38 @@ func ParseGameTree(reader io.RuneScanner) (*structures.GameTree, error) {
 // Sequence = Node { Node }
 func ParseSequence(reader io.RuneScanner) (*structures.Sequence, error) {
 	seq := new(structures.Sequence)
+	pr := track(reader)
 
 	for {
 
-		currRune, _, err := reader.ReadRune()
+		currRune, _, err := pr.ReadRune()
 		if err != nil {
-			return nil, err
+			return nil, newSyntaxError(pr, SequenceElement, err, "")
 		}
 
 		if currRune == structures.GameTreeEnd {
-			err = reader.UnreadRune()
+			err = pr.UnreadRune()
 			if err != nil {
-				return nil, err
+				return nil, newSyntaxError(pr, SequenceElement, err, "")
 			}
 			break
 		}
 
 		// for subtrees
 		if currRune == structures.GameTreeStart {
-			err = reader.UnreadRune()
+			err = pr.UnreadRune()
 			if err != nil {
-				return nil, err
+				return nil, newSyntaxError(pr, SequenceElement, err, "")
 			}
 			break
 		}
 
 		if currRune == structures.NodeSeparator {
-			err = reader.UnreadRune()
-			node, err := ParseNode(reader)
+			err = pr.UnreadRune()
+			if err != nil {
+				return nil, newSyntaxError(pr, SequenceElement, err, "")
+			}
+			node, err := ParseNode(pr)
 			if err != nil {
 				return nil, err
 			}
@@ -150,7 +151,7 @@ func ParseSequence(reader io.RuneScanner) (*structures.Sequence, error) {
 	}
 
 	if len(seq.Nodes) < 1 {
-		return nil, errors.New("Sequence must contain at least one node!")
+		return nil, newSyntaxError(pr, SequenceElement, ParseError, "Sequence must contain at least one node!")
 	}
 
 	return seq, nil
@@ -159,28 +160,43 @@ func ParseSequence(reader io.RuneScanner) (*structures.Sequence, error) {
 // ParseNode parses an entire Node with all its properties. The function will search for the first
 // node separator and parse 1 node. It will NOT consume the next node separator (if any) or the game tree end
 // Node = ";" { Property }
+//
+// As per FF[4] a property identifier may appear only once within a node. Duplicates are reported with DuplicatePropertyError.
 func ParseNode(reader io.RuneScanner) (*structures.Node, error) {
 
-	var node structures.Node
+	node := structures.Node{Properties: []structures.Property{}}
+	pr := track(reader)
 
+	// spin to the current node start
 	for {
-		currRune, _, err := reader.ReadRune()
+		currRune, _, err := pr.ReadRune()
 		if err != nil {
-			return nil, err
+			return nil, newSyntaxError(pr, NodeElement, err, "could not find node start")
 		}
 
 		if currRune == structures.NodeSeparator {
-			property, err := ParseProperty(reader)
-			if err != nil {
-				if err == EmptyNodeError {
-					return new(structures.Node), nil
-				}
-				return nil, err
+			break
+		}
+	}
+
+	seen := make(map[structures.PropIdent]bool)
+
+	// parse properties until the next node, variation or game tree end is reached
+	for {
+		property, err := ParseProperty(pr)
+		if err != nil {
+			if errors.Is(err, EmptyNodeError) {
+				break
 			}
+			return nil, err
+		}
 
-			node.Properties = append(node.Properties, *property)
-			break
+		if seen[property.Ident] {
+			return nil, newSyntaxError(pr, NodeElement, DuplicatePropertyError, string(property.Ident))
 		}
+		seen[property.Ident] = true
+
+		node.Properties = append(node.Properties, *property)
 	}
 
 	return &node, nil
@@ -188,36 +204,49 @@ func ParseNode(reader io.RuneScanner) (*structures.Node, error) {
 
 // Parses a Property. As per specification a property consist of one PropIdent and one or more unordered PropValues:
 // Property = PropIdent PropValue { PropValue }
-// TODO: In the future this method will check if the PropValue(s) have a type, suitable for the PropIdent.
+// The values are normalized depending on the type of the PropIdent (see structures.NormalizeValueFor).
+// The values of known properties are checked against the type of the PropIdent. Mismatches are logged, but do not fail
+// the parsing - use Property.Check or the typed accessors (Property.AsNumber etc.) to handle them.
 func ParseProperty(reader io.RuneScanner) (*structures.Property, error) {
 	var prop structures.Property
+	pr := track(reader)
 
-	ident, err := ParsePropIdent(reader)
+	ident, start, err := parsePropIdent(pr)
 	if err != nil {
-		if err == EmptyNodeError {
-			return nil, err
-		}
-		return nil, errors.New(fmt.Sprintf("Failed to parse PropIdent. %s", err.Error()))
+		return nil, err
 	}
 
-	prop.Ident = *ident
+	prop.Ident = ident
 
 	for {
-		err := seekToNextPropValue(reader)
+		err := seekToNextPropValue(pr)
 		if err != nil {
 			if err == ElementEndError {
 				break
 			} else {
-				return nil, err
+				return nil, newSyntaxError(pr, PropValueElement, err, "")
 			}
 		}
 
-		val, err := ParsePropValue(reader)
+		raw, err := parseRawPropValue(pr)
 		if err != nil {
-			return nil, errors.New(fmt.Sprintf("Failed to parse PropValue. %s", err.Error()))
+			return nil, err
 		}
 
-		prop.Values = append(prop.Values, *val)
+		if pr.values == RawValues {
+			prop.Values = append(prop.Values, structures.PropValue(raw))
+		} else {
+			prop.Values = append(prop.Values, structures.NormalizeValueFor(prop.Ident, raw))
+		}
+		prop.Raw = append(prop.Raw, structures.PropValue(raw))
+	}
+
+	if pr.compatibility {
+		convertOldProperty(pr, &prop, start)
+	}
+
+	if err := prop.Check(); err != nil {
+		logger.LogWarn(err.Error())
 	}
 
 	return &prop, nil
@@ -226,12 +255,23 @@ func ParseProperty(reader io.RuneScanner) (*structures.Property, error) {
 // Parses a PropIdent. As per specification PropIdent a word, containing 1 or 2 upper case letter(s). Space, tab, new line etc are also allowed.
 // Validation whether the PropIdent is known or not will not be made here!
 func ParsePropIdent(reader io.RuneScanner) (*structures.PropIdent, error) {
+	propIdent, _, err := parsePropIdent(track(reader))
+	if err != nil {
+		return nil, err
+	}
+	return &propIdent, nil
+}
+
+// parsePropIdent parses a PropIdent and returns it with the position of its first letter. In compatibility mode lower
+// case letters are dropped (see Options.Compatibility).
+func parsePropIdent(pr *positionReader) (structures.PropIdent, position, error) {
 	var propIdent structures.PropIdent
+	start := pr.next
 
 	for {
-		currRune, _, err := reader.ReadRune()
+		currRune, _, err := pr.ReadRune()
 		if err != nil {
-			return nil, err
+			return "", start, newSyntaxError(pr, PropIdentElement, err, "")
 		}
 
 		if currRune == unicode.ReplacementChar {
@@ -240,32 +280,39 @@ func ParsePropIdent(reader io.RuneScanner) (*structures.PropIdent, error) {
 		}
 
 		if currRune == structures.NodeSeparator || currRune == structures.GameTreeStart || currRune == structures.GameTreeEnd {
-			err = reader.UnreadRune()
+			err = pr.UnreadRune()
 			if err != nil {
-				return nil, err
+				return "", start, newSyntaxError(pr, PropIdentElement, err, "")
 			}
-			return nil, EmptyNodeError
+			return "", start, newSyntaxError(pr, PropIdentElement, EmptyNodeError, "")
 		}
 
 		if currRune == structures.PropertyValueStart {
 			// Unread the last rune so that ParsePropValue can start parsing
-			err = reader.UnreadRune()
+			err = pr.UnreadRune()
 			if err != nil {
-				return nil, err
+				return "", start, newSyntaxError(pr, PropIdentElement, err, "")
 			}
 
 			break
 		}
 
+		if strings.TrimSpace(string(propIdent)) == "" {
+			start = pr.last
+		}
 		propIdent += structures.PropIdent(currRune)
 	}
 
 	propIdent = structures.PropIdent(strings.Trim(string(propIdent), " \t\n"))
 
+	if pr.compatibility {
+		propIdent = convertOldIdent(pr, propIdent, start)
+	}
+
 	if !isValid(propIdent) {
-		return nil, errors.New(fmt.Sprintf("PropIdent %s is invalid!", propIdent))
+		return "", start, newSyntaxError(pr, PropIdentElement, ParseError, fmt.Sprintf("PropIdent %s is invalid!", propIdent))
 	}
-	return &propIdent, nil
+	return propIdent, start, nil
 }
 
 func isValid(propIdent structures.PropIdent) bool {
@@ -301,14 +348,27 @@ func isValid(propIdent structures.PropIdent) bool {
 // Compose    = ValueType ":" ValueType
 //
 // This Parser will not recognize the Value Type, but will strip some symbols, which are common for all types (e.g. tabs will become spaces).
+// See structures.NormalizeValue for the details.
 func ParsePropValue(reader io.RuneScanner) (*structures.PropValue, error) {
-	var propValue structures.PropValue
+	raw, err := parseRawPropValue(track(reader))
+	if err != nil {
+		return nil, err
+	}
+
+	propValue := structures.NormalizeValue(raw)
+	return &propValue, nil
+}
+
+// parseRawPropValue parses a PropValue and returns the text between the brackets as it is - escape characters and line
+// breaks are kept. Only invalid unicode characters are dropped.
+func parseRawPropValue(pr *positionReader) (string, error) {
+	var raw strings.Builder
 
 	// seek to the first PropertyValueStart rune
 	for {
-		currRune, _, err := reader.ReadRune()
+		currRune, _, err := pr.ReadRune()
 		if err != nil {
-			return nil, errors.New(fmt.Sprintf("Could not find PropertyValueStart rune. %s", err.Error()))
+			return "", newSyntaxError(pr, PropValueElement, err, "Could not find PropertyValueStart rune")
 		}
 		if currRune == structures.PropertyValueStart {
 			break
@@ -319,19 +379,12 @@ func ParsePropValue(reader io.RuneScanner) (*structures.PropValue, error) {
 	doEscape := false
 
 	for {
-		currRune, _, err := reader.ReadRune()
+		currRune, _, err := pr.ReadRune()
 		if err != nil {
 			if err == io.EOF {
-				break
-			} else {
-				return nil, ParseError
+				return "", newSyntaxError(pr, PropValueElement, err, "unterminated value")
 			}
-		}
-
-		// enter escape only if we are not escaping already
-		if currRune == escapeChar && !doEscape {
-			doEscape = true
-			continue
+			return "", newSyntaxError(pr, PropValueElement, err, "")
 		}
 
 		// invalid char - ignore
@@ -340,60 +393,23 @@ func ParsePropValue(reader io.RuneScanner) (*structures.PropValue, error) {
 			continue
 		}
 
-		// replace tabs with spaces (as per spec)
-		if currRune == '\t' {
-			currRune = ' '
-		}
-
-		// if we are in escape mode and we encounter CR or LF
-		if doEscape && (currRune == '\n' || currRune == '\r') {
-			nextRune, _, err := reader.ReadRune()
-			if err != nil {
-				return nil, err
-			}
-
-			// if the current + next rune do not make a CRLF or LFCR sequence - unread it and discard only the single CR or LF
-			if (currRune == '\n' && nextRune != '\r') || (currRune == '\r' && nextRune != '\n') {
-				reader.UnreadRune()
-			}
-
-			// remove the new line if it's a "soft line break"
-			doEscape = false
-			continue
-		}
-
-		// if we've reached the end of the property
+		// end parsing the current propValue only if ] is not escaped
 		if !doEscape && currRune == structures.PropertyValueEnd {
-			// end parsing the current propValue only if ] is not escaped
-			// Unread the last rune so that the caller knows that we've reached the propvalue end
-			err = reader.UnreadRune()
-			if err != nil {
-				return nil, err
-			}
 			break
 		}
 
-		propValue += structures.PropValue(currRune)
-
-		doEscape = false
-	}
+		raw.WriteRune(currRune)
 
-	lastRune, _, err := reader.ReadRune()
-	if err != nil {
-		return nil, err
-	}
-
-	if lastRune != structures.PropertyValueEnd {
-		// we've exited the loop for some unusual reason. Return error
-		return nil, errors.New("Property Value seems invalid")
+		// enter escape only if we are not escaping already
+		doEscape = currRune == escapeChar && !doEscape
 	}
 
-	return &propValue, nil
+	return raw.String(), nil
 }
 
-// This method will advance the reader to the next occurence of PropertyValueStart within the current Node/GameTree
+// This method will advance the reader to the next occurence of PropertyValueStart within the current Property.
 // The reader is supposed to be pointing either to the end of a Property or to a place between two PropValues.
-// If it's pointing to the end of a property, this method will return ElementEndError.
+// If it's pointing to the end of a property (next PropIdent, node or variation), this method will return ElementEndError.
 // Otherwise it will either return nil (seek successful) or another error
 func seekToNextPropValue(reader io.RuneScanner) error {
 	for {
@@ -402,13 +418,9 @@ func seekToNextPropValue(reader io.RuneScanner) error {
 			return err
 		}
 
-		// stop if next variation or node is reached and return ElementEndError
-		if currRune == structures.NodeSeparator || currRune == structures.GameTreeStart || currRune == structures.GameTreeEnd {
-			err = reader.UnreadRune()
-			if err != nil {
-				return err
-			}
-			return ElementEndError
+		// white space is allowed between values
+		if unicode.IsSpace(currRune) {
+			continue
 		}
 
 		if currRune == structures.PropertyValueStart {
@@ -418,7 +430,12 @@ func seekToNextPropValue(reader io.RuneScanner) error {
 			}
 			return nil
 		}
-	}
 
-	return errors.New("Could not find PropValue end")
+		// stop if next property, variation or node is reached and return ElementEndError
+		err = reader.UnreadRune()
+		if err != nil {
+			return err
+		}
+		return ElementEndError
+	}
 }
@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/makpoc/sgfparser/charset"
 	"github.com/makpoc/sgfparser/parser"
 	"github.com/makpoc/sgfparser/structures"
 
@@ -152,7 +153,7 @@ func TestParsePropIdent(t *testing.T) {
 		result, err := parser.ParsePropIdent(reader)
 
 		if err != nil {
-			t.Errorf("Test %d returned error!", i, err.Error())
+			t.Errorf("Test %d returned error! %s", i, err.Error())
 		}
 
 		if *result != current.parsed {
@@ -465,6 +466,58 @@ func TestParseNode(t *testing.T) {
 				},
 			},
 		},
+		nodeStruct{
+			"(;B[pd]N[Moves]\nC[comment];W[dp])",
+			structures.Node{
+				Properties: []structures.Property{
+					structures.Property{
+						Ident: structures.PropIdent("B"),
+						Values: []structures.PropValue{
+							structures.PropValue("pd"),
+						},
+					},
+					structures.Property{
+						Ident: structures.PropIdent("N"),
+						Values: []structures.PropValue{
+							structures.PropValue("Moves"),
+						},
+					},
+					structures.Property{
+						Ident: structures.PropIdent("C"),
+						Values: []structures.PropValue{
+							structures.PropValue("comment"),
+						},
+					},
+				},
+			},
+		},
+		nodeStruct{
+			"(;AB[dd][de]\n  AW[jd] [je]C[x](;B[aa]))",
+			structures.Node{
+				Properties: []structures.Property{
+					structures.Property{
+						Ident: structures.PropIdent("AB"),
+						Values: []structures.PropValue{
+							structures.PropValue("dd"),
+							structures.PropValue("de"),
+						},
+					},
+					structures.Property{
+						Ident: structures.PropIdent("AW"),
+						Values: []structures.PropValue{
+							structures.PropValue("jd"),
+							structures.PropValue("je"),
+						},
+					},
+					structures.Property{
+						Ident: structures.PropIdent("C"),
+						Values: []structures.PropValue{
+							structures.PropValue("x"),
+						},
+					},
+				},
+			},
+		},
 		nodeStruct{
 			"(;)",
 			structures.Node{
@@ -529,6 +582,11 @@ func TestParseNodeNeg(t *testing.T) {
 				},
 			},
 		},
+		nodeStruct{
+			// duplicate property identifiers are not allowed
+			";B[aa]C[x]B[bb])",
+			structures.Node{},
+		},
 	}
 
 	for i, current := range nodesMatrix {
@@ -538,7 +596,7 @@ func TestParseNodeNeg(t *testing.T) {
 		if err == nil {
 			t.Errorf("%d: Test expected to return error but did not!", i)
 			if result != nil {
-				t.Errorf(fmt.Sprintf("Instead the returned value was: \n%#v", *result))
+				t.Errorf("Instead the returned value was: \n%#v", *result)
 			}
 		}
 	}
@@ -1131,6 +1189,344 @@ func TestParseCollection(t *testing.T) {
 	}
 }
 
+func TestParseCollectionWithOptions(t *testing.T) {
+	type optionsStruct struct {
+		raw         string
+		recovery    parser.RecoveryMode
+		trees       []string
+		diagnostics []parser.Action
+		isError     bool
+	}
+
+	var optionsMatrix = []optionsStruct{
+		{"(;C[a])(;C[b])", parser.FailFast, []string{"(;C[a])", "(;C[b])"}, nil, false},
+		{"(;C[a])()(;C[b])", parser.FailFast, nil, nil, true},
+		{"(;C[a])(;C[x]Bad[y](;E[)]))(;C[b])", parser.SkipBadTree, []string{"(;C[a])", "(;C[b])"}, []parser.Action{parser.TreeSkipped}, false},
+		{"(;C[a])(;C[b](;C[c]", parser.SkipBadTree, []string{"(;C[a])"}, []parser.Action{parser.TreeSkipped}, false},
+		{"(;C[a])(;C[b](;C[c]", parser.BestEffort, []string{"(;C[a])", "(;C[b](;C[c]))"}, []parser.Action{parser.TreeRepaired}, false},
+		{"(;C[a])(;C[b](;C[c\\", parser.BestEffort, []string{"(;C[a])", "(;C[b](;C[c]))"}, []parser.Action{parser.TreeRepaired, parser.TreeRepaired}, false},
+		{"()(;C[a](;C[b]", parser.BestEffort, []string{"(;C[a](;C[b]))"}, []parser.Action{parser.TreeSkipped, parser.TreeRepaired}, false},
+		{"(;C[a];C[b)\n(;C[c)\n(;C[d)", parser.BestEffort, []string{"(;C[a];C[b])", "(;C[c])", "(;C[d)])"}, []parser.Action{parser.TreeRepaired, parser.TreeRepaired, parser.TreeRepaired, parser.TreeRepaired}, false},
+		{"(;C[a](;C[b)(;C[c)", parser.BestEffort, []string{"(;C[a](;C[b]))", "(;C[c)])"}, []parser.Action{parser.TreeRepaired, parser.TreeRepaired, parser.TreeRepaired, parser.TreeRepaired}, false},
+		// values closed later are kept, even if they contain the end of a game tree
+		{"(;C[a)(b];C[c)(;C[d])", parser.BestEffort, []string{"(;C[a)(b];C[c)(;C[d])"}, nil, false},
+		{"(;C[a];C[b)\n(;C[c])\n(;C[d)", parser.BestEffort, []string{"(;C[a];C[b)\n(;C[c])", "(;C[d)])"}, []parser.Action{parser.TreeRepaired, parser.TreeRepaired}, false},
+	}
+
+	for i, current := range optionsMatrix {
+		result, diagnostics, err := parser.ParseCollectionWithOptions(getReader(current.raw), parser.Options{Recovery: current.recovery})
+
+		if current.isError {
+			if err == nil || result != nil {
+				t.Errorf("Test %d expected an error, got %v", i, result)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Test %d returned error! %s", i, err.Error())
+			continue
+		}
+
+		if len(result.GameTrees) != len(current.trees) {
+			t.Errorf("Test %d: expected %d game trees, found %d", i, len(current.trees), len(result.GameTrees))
+			continue
+		}
+		for j, tree := range result.GameTrees {
+			if tree.String() != current.trees[j] {
+				t.Errorf("Test %d: expected tree %s, found %s", i, current.trees[j], tree)
+			}
+		}
+
+		if len(diagnostics) != len(current.diagnostics) {
+			t.Errorf("Test %d: expected %d diagnostics, found %v", i, len(current.diagnostics), diagnostics)
+			continue
+		}
+		for j, diagnostic := range diagnostics {
+			if diagnostic.Action != current.diagnostics[j] {
+				t.Errorf("Test %d: expected diagnostic %s, found %s", i, current.diagnostics[j], diagnostic)
+			}
+		}
+	}
+}
+
+func TestBestEffortPositions(t *testing.T) {
+	_, diagnostics, err := parser.ParseCollectionWithOptions(getReader("(;C[a];C[b)\n(;Bad[x)"), parser.Options{Recovery: parser.BestEffort})
+	if err != nil {
+		t.Fatalf("ParseCollectionWithOptions returned error! %s", err.Error())
+	}
+	if len(diagnostics) != 2 {
+		t.Fatalf("Expected 2 diagnostics, found %v", diagnostics)
+	}
+	// the repair points to the start of the unterminated value, the skipped tree to its real position
+	if d := diagnostics[0]; d.Action != parser.TreeRepaired || d.Line != 1 || d.Column != 9 {
+		t.Errorf("Expected the repair at line 1, column 9, found %s", d)
+	}
+	if d := diagnostics[1]; d.Action != parser.TreeSkipped || d.TreeIndex != 1 || d.Line != 2 {
+		t.Errorf("Expected the second tree to be skipped at line 2, found %s", d)
+	}
+}
+
+func TestValueWithGameTreeEnd(t *testing.T) {
+	var inputs = []string{
+		"(;FF[4]C[smile :)\n(; wink];B[cc])",
+		"(;FF[4]C[(;B[aa\\])(;B[bb\\])];B[cc])",
+	}
+
+	for i, input := range inputs {
+		for _, recovery := range []parser.RecoveryMode{parser.FailFast, parser.SkipBadTree, parser.BestEffort} {
+			result, diagnostics, err := parser.ParseCollectionWithOptions(getReader(input), parser.Options{Recovery: recovery})
+			if err != nil {
+				t.Errorf("Test %d: %v returned error! %s", i, recovery, err.Error())
+				continue
+			}
+			if len(result.GameTrees) != 1 || len(diagnostics) != 0 || result.GameTrees[0].String() != input {
+				t.Errorf("Test %d: %v: expected %s, found %s %v", i, recovery, input, result, diagnostics)
+			}
+		}
+	}
+}
+
+func TestSkippedTreePath(t *testing.T) {
+	type pathStruct struct {
+		input string
+		path  string
+	}
+
+	var pathMatrix = []pathStruct{
+		{"(;Bad[x])", "0.0"},
+		{"(;C[a])(;C[b];B[aa]B[bb])", "1.1"},
+		{"(;C[a];B[aa](;W[bb])(;W[cc]C[)(;];B[dd]Bx[ee]))", "0.1-1.2"},
+		{"(;C[a](;B[aa](;W[bb];B[cc])(;W[dd]))(;B[ee];W[ff]Bad[x]))", "0.0-1.2"},
+		{"(;C[a];B[aa](;W[bb]", "0.2"},
+	}
+
+	for i, current := range pathMatrix {
+		_, diagnostics, err := parser.ParseCollectionWithOptions(getReader(current.input), parser.DefaultOptions)
+		if err != nil {
+			t.Fatalf("Test %d: ParseCollectionWithOptions returned error! %s", i, err.Error())
+		}
+		if len(diagnostics) != 1 || diagnostics[0].Path != current.path {
+			t.Errorf("Test %d: expected a skipped tree at %s, found %v", i, current.path, diagnostics)
+		}
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	decoder := parser.NewDecoder(getReader("(;C[a](;C[b])(;C[c]))\n()\n(;C[d])"), parser.DefaultOptions)
+
+	var trees []string
+	for gTree, err := range decoder.All() {
+		if err != nil {
+			t.Fatalf("Decoder returned error! %s", err.Error())
+		}
+		trees = append(trees, gTree.String())
+	}
+
+	expected := []string{"(;C[a](;C[b])(;C[c]))", "(;C[d])"}
+	if strings.Join(trees, "") != strings.Join(expected, "") {
+		t.Errorf("Expected %v, found %v", expected, trees)
+	}
+	if diagnostics := decoder.Diagnostics(); len(diagnostics) != 1 || diagnostics[0].TreeIndex != 1 || diagnostics[0].Line != 2 {
+		t.Errorf("Expected the second tree to be skipped, found %v", diagnostics)
+	}
+
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF after the last tree, found %v", err)
+	}
+
+	collection, _, err := parser.ParseCollectionWithOptions(getReader("(;C[a](;C[b];C[c])(;C[d]))(;C[e])"), parser.DefaultOptions)
+	if err != nil {
+		t.Fatalf("ParseCollectionWithOptions returned error! %s", err.Error())
+	}
+	for id, expected := range []string{"a", "b", "c", "d", "e"} {
+		node, found := collection.NodeByID(id + 1)
+		if !found || string(node.Properties[0].Values[0]) != expected {
+			t.Errorf("Expected node %d to be C[%s], found %v", id+1, expected, node)
+		}
+	}
+
+	decoder = parser.NewDecoder(getReader("(;C[a])()(;C[b])"), parser.Options{Recovery: parser.FailFast})
+	if gTree, err := decoder.Next(); err != nil || gTree.String() != "(;C[a])" {
+		t.Errorf("Expected the first tree, found %v (%v)", gTree, err)
+	}
+	if _, err := decoder.Next(); !errors.Is(err, parser.ParseError) {
+		t.Errorf("Expected a parse error, found %v", err)
+	}
+	if _, err := decoder.Next(); !errors.Is(err, parser.ParseError) {
+		t.Errorf("Expected the decoder to stop after the error, found %v", err)
+	}
+}
+
+func TestValueModes(t *testing.T) {
+	raw := "(;N[a\nb\\\nc\td]C[a\r\nb\\\r\nc\td]GN[x\r\ny]LB[dd:A\nB]SZ[ 19 ])"
+
+	type valueStruct struct {
+		mode   parser.ValueMode
+		values []string
+	}
+
+	var valueMatrix = []valueStruct{
+		{parser.NormalizedValues, []string{"a bc d", "a\r\nbc d", "x y", "dd:A B", " 19 "}},
+		{parser.RawValues, []string{"a\nb\\\nc\td", "a\r\nb\\\r\nc\td", "x\r\ny", "dd:A\nB", " 19 "}},
+	}
+
+	for i, current := range valueMatrix {
+		collection, _, err := parser.ParseCollectionWithOptions(getReader(raw), parser.Options{Recovery: parser.FailFast, Values: current.mode})
+		if err != nil {
+			t.Fatalf("Test %d returned error! %s", i, err.Error())
+		}
+
+		for j, prop := range collection.GameTrees[0].Sequence.Nodes[0].Properties {
+			if string(prop.Values[0]) != current.values[j] {
+				t.Errorf("Test %d: expected %s[%q], found %q", i, prop.Ident, current.values[j], prop.Values[0])
+			}
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	type openStruct struct {
+		raw      string
+		fallback string
+		charset  string
+		comment  string
+		err      error
+	}
+
+	var openMatrix = []openStruct{
+		{"(;FF[4]CA[UTF-8]C[café])", "", "", "café", nil},
+		{"(;FF[4]CA[ISO-8859-1]C[caf\xe9 \xbd])", "", "ISO-8859-1", "café ½", nil},
+		{"(;FF[4]C[caf\xe9]CA[latin1])", "", "latin1", "café", nil},
+		{"(;FF[4]C[a\\]CA[x\\]];C[caf\xe9])", "ISO-8859-1", "ISO-8859-1", "a]CA[x]", nil},
+		{"(;FF[4]C[caf\xe9];CA[UTF-8])", "ISO-8859-1", "ISO-8859-1", "café", nil},
+		{"(;FF[4]C[caf\xe9])", "", "ISO-8859-1", "café", nil},
+		{"(;FF[4]C[café])", "UTF-8", "", "café", nil},
+		{"(;FF[4]CA[Shift_JIS]C[\x88\xcd\x8c\xe9 \x95\\])", "", "Shift_JIS", "囲碁 表", nil},
+		{"(;FF[4]CA[GB2312]C[\xce\xa7\xc6\xe5])", "", "GB2312", "围棋", nil},
+		{"(;FF[4]CA[EUC-KR]C[\xb9\xd9\xb5\xcf])", "", "EUC-KR", "바둑", nil},
+		{"(;FF[4]CA[KOI8-R]C[a])", "", "", "", charset.UnknownCharsetError},
+		{"(;FF[4]C[a])", "Big5", "", "", charset.UnknownCharsetError},
+	}
+
+	for i, current := range openMatrix {
+		decoder, err := parser.Open(strings.NewReader(current.raw), parser.Options{Recovery: parser.FailFast, FallbackCharset: current.fallback})
+		if current.err != nil {
+			if !errors.Is(err, current.err) {
+				t.Errorf("Test %d: expected error %v, found %v", i, current.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d returned error! %s", i, err.Error())
+		}
+
+		gTree, err := decoder.Next()
+		if err != nil {
+			t.Fatalf("Test %d returned error! %s", i, err.Error())
+		}
+		if decoder.Charset() != current.charset {
+			t.Errorf("Test %d: expected charset %q, found %q", i, current.charset, decoder.Charset())
+		}
+		for _, prop := range gTree.Sequence.Nodes[0].Properties {
+			if prop.Ident == "C" && string(prop.Values[0]) != current.comment {
+				t.Errorf("Test %d: expected comment %q, found %q", i, current.comment, prop.Values[0])
+			}
+		}
+	}
+}
+
+func TestCompatibility(t *testing.T) {
+	raw := "(;FF[3]GaMe[1]SiZe[9]ID[42]\n;AddBlack[aa][bb]Black[cc]Comment[old style]\n;W[dd]L[ee][ff]M[gg])"
+
+	collection, diagnostics, err := parser.ParseCollectionWithOptions(getReader(raw), parser.Options{Recovery: parser.FailFast, Compatibility: true})
+	if err != nil {
+		t.Fatalf("Parse returned error! %s", err.Error())
+	}
+
+	expected := "(;FF[4]GM[1]SZ[9]ID[42];AB[aa][bb]B[cc]C[old style];W[dd]LB[ee:a][ff:b]MA[gg])"
+	if collection.GameTrees[0].String() != expected {
+		t.Errorf("Expected %s, found %s", expected, collection.GameTrees[0].String())
+	}
+
+	type reportStruct struct {
+		line   int
+		column int
+		msg    string
+	}
+
+	var reportMatrix = []reportStruct{
+		{1, 3, "FF[3] converted to FF[4]"},
+		{1, 8, "GaMe converted to GM"},
+		{1, 15, "SiZe converted to SZ"},
+		{1, 22, "ID (game identifier) is obsolete in FF[4], kept unchanged"},
+		{2, 2, "AddBlack converted to AB"},
+		{2, 18, "Black converted to B"},
+		{2, 27, "Comment converted to C"},
+		{3, 7, "L converted to LB"},
+		{3, 16, "M converted to MA"},
+	}
+
+	if len(diagnostics) != len(reportMatrix) {
+		t.Fatalf("Expected %d conversions, found %v", len(reportMatrix), diagnostics)
+	}
+	for i, current := range reportMatrix {
+		diagnostic := diagnostics[i]
+		if diagnostic.Action != parser.PropertyConverted || diagnostic.Line != current.line || diagnostic.Column != current.column || diagnostic.Msg != current.msg {
+			t.Errorf("Test %d: expected %q at %d:%d, found %s", i, current.msg, current.line, current.column, diagnostic)
+		}
+	}
+
+	// without the compatibility mode old identifiers are rejected
+	if _, _, err := parser.ParseCollectionWithOptions(getReader(raw), parser.Options{Recovery: parser.FailFast}); !errors.Is(err, parser.ParseError) {
+		t.Errorf("Expected a parse error without compatibility mode, found %v", err)
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	type errorStruct struct {
+		raw     string
+		element parser.Element
+		line    int
+		column  int
+		offset  int64
+		r       rune
+		cause   error
+	}
+
+	var errorMatrix = []errorStruct{
+		{"(;FF[4]\n;Bx[aa])", parser.PropIdentElement, 2, 3, 10, 'x', parser.ParseError},
+		{"(;FF[4]\n;B[aa]C[x]B[bb])", parser.NodeElement, 2, 15, 22, ']', parser.DuplicatePropertyError},
+		{"(;FF[4];C[abc", parser.PropValueElement, 1, 14, 13, 0, io.EOF},
+		{"(;FF[4]()", parser.SequenceElement, 1, 8, 7, '(', parser.ParseError},
+	}
+
+	for i, current := range errorMatrix {
+		_, err := parser.ParseGameTree(getReader(current.raw))
+		if err == nil {
+			t.Errorf("Test %d expected an error, got nil", i)
+			continue
+		}
+
+		var syntaxErr *parser.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Test %d: expected *SyntaxError, got %T", i, err)
+			continue
+		}
+
+		if !errors.Is(err, parser.ParseError) || !errors.Is(err, current.cause) {
+			t.Errorf("Test %d: error %q does not match %q", i, err.Error(), current.cause.Error())
+		}
+
+		if syntaxErr.Element != current.element || syntaxErr.Line != current.line || syntaxErr.Column != current.column ||
+			syntaxErr.Offset != current.offset || syntaxErr.Rune != current.r {
+			t.Errorf("Test %d: expected %s at %d:%d (offset %d, rune %q), found %s at %d:%d (offset %d, rune %q)", i,
+				current.element, current.line, current.column, current.offset, current.r,
+				syntaxErr.Element, syntaxErr.Line, syntaxErr.Column, syntaxErr.Offset, syntaxErr.Rune)
+		}
+	}
+}
+
 func compareProperties(expected, actual structures.Property) error {
 	if &expected == &actual {
 		// same object
@@ -0,0 +1,326 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/makpoc/sgfparser/structures"
+)
+
+// position describes a place within the parsed input. Line and Column are 1-based, Offset is the byte offset from the start.
+type position struct {
+	Line   int
+	Column int
+	Offset int64
+}
+
+// positionReader wraps an io.RuneScanner and keeps track of the position of the last rune read.
+// Like bufio.Reader it supports only a single UnreadRune after ReadRune.
+//
+// The positionReader is passed through all parse functions, so it also carries the parsing options, which are not
+// part of the grammar.
+type positionReader struct {
+	reader        io.RuneScanner
+	values        ValueMode
+	compatibility bool
+	// conversions lists the FF[1]-FF[3] constructs converted in compatibility mode
+	conversions []repair
+
+	// next is the position of the next rune to be read
+	next position
+	// last is the position of the last rune read and lastRune - the rune itself
+	last     position
+	lastRune rune
+
+	// state before the last ReadRune, restored on UnreadRune
+	prevNext     position
+	prevLast     position
+	prevLastRune rune
+}
+
+// track returns a positionReader for the given reader. If the reader is already tracked it's returned as is,
+// so that nested parse functions report positions relative to the beginning of the input.
+func track(reader io.RuneScanner) *positionReader {
+	if pr, ok := reader.(*positionReader); ok {
+		return pr
+	}
+	start := position{Line: 1, Column: 1}
+	return &positionReader{reader: reader, next: start, last: start}
+}
+
+func (pr *positionReader) ReadRune() (rune, int, error) {
+	currRune, size, err := pr.reader.ReadRune()
+	if err != nil {
+		// report errors at the end of the input
+		pr.last = pr.next
+		pr.lastRune = 0
+		return currRune, size, err
+	}
+
+	pr.prevNext, pr.prevLast, pr.prevLastRune = pr.next, pr.last, pr.lastRune
+
+	pr.last = pr.next
+	pr.lastRune = currRune
+
+	pr.next.Offset += int64(size)
+	if currRune == '\n' {
+		pr.next.Line++
+		pr.next.Column = 1
+	} else {
+		pr.next.Column++
+	}
+
+	return currRune, size, nil
+}
+
+func (pr *positionReader) UnreadRune() error {
+	if err := pr.reader.UnreadRune(); err != nil {
+		return err
+	}
+	pr.next, pr.last, pr.lastRune = pr.prevNext, pr.prevLast, pr.prevLastRune
+	return nil
+}
+
+// trackFrom returns a positionReader, which reports positions starting at the given one.
+// It is used to parse parts of the input, which were already read, while keeping the original positions.
+func trackFrom(reader io.RuneScanner, start position) *positionReader {
+	return &positionReader{reader: reader, next: start, last: start}
+}
+
+// pushBack makes the reader return the text before the rest of the input. at is the position of the text in the input.
+func (pr *positionReader) pushBack(text string, at position) {
+	pr.reader = &prefixReader{prefix: strings.NewReader(text), reader: pr.reader}
+	pr.next, pr.last, pr.lastRune = at, at, 0
+}
+
+// prefixReader reads the prefix and then the reader
+type prefixReader struct {
+	prefix     *strings.Reader
+	reader     io.RuneScanner
+	fromPrefix bool
+}
+
+func (r *prefixReader) ReadRune() (rune, int, error) {
+	r.fromPrefix = r.prefix.Len() > 0
+	if r.fromPrefix {
+		return r.prefix.ReadRune()
+	}
+	return r.reader.ReadRune()
+}
+
+func (r *prefixReader) UnreadRune() error {
+	if r.fromPrefix {
+		return r.prefix.UnreadRune()
+	}
+	return r.reader.UnreadRune()
+}
+
+// rawGameTree is the unparsed text of a top level game tree
+type rawGameTree struct {
+	text  string
+	start position
+	// repairs lists the changes made to the text in BestEffort mode
+	repairs []repair
+}
+
+// repair describes a change made to the input
+type repair struct {
+	pos position
+	msg string
+}
+
+// convert records a conversion made in compatibility mode
+func (pr *positionReader) convert(at position, msg string) {
+	pr.conversions = append(pr.conversions, repair{at, msg})
+}
+
+// boundary tracks the first end of a game tree followed by the start of another one (e.g. ")\n(;") inside a value
+type boundary struct {
+	// state is 0 outside of a boundary, 1 after ')', 2 after '(' and 3 once the boundary is complete
+	state int
+	// end is the index of ')' in the text and endPos its position, next and nextPos - the same for '('
+	end     int
+	endPos  position
+	next    int
+	nextPos position
+}
+
+// add checks the next unescaped rune of a value at the index in the text. The first complete boundary is kept.
+func (b *boundary) add(r rune, index int, at position) {
+	switch {
+	case b.state == 3:
+	case r == structures.GameTreeEnd:
+		b.state, b.end, b.endPos = 1, index, at
+	case b.state > 0 && unicode.IsSpace(r):
+	case b.state == 1 && r == structures.GameTreeStart:
+		b.state, b.next, b.nextPos = 2, index, at
+	case b.state == 2 && r == structures.NodeSeparator:
+		b.state = 3
+	default:
+		b.state = 0
+	}
+}
+
+// escape resets an incomplete boundary, as escaped runes can not be a part of it
+func (b *boundary) escape() {
+	if b.state != 3 {
+		b.state = 0
+	}
+}
+
+// readGameTree reads the next top level game tree from the reader without parsing it. Values are skipped as a whole,
+// so that parentheses inside them do not affect the nesting. Anything before the game tree start is discarded.
+// It returns io.EOF if there are no more game trees in the reader. On other errors the text read so far is returned.
+//
+// If doRepair is true, unterminated values and game trees at the end of the input are closed. If an unterminated value
+// runs over the end of a game tree into the start of another one (e.g. "C[unterminated)\n(;FF[4]"), it's closed
+// together with the game tree at that boundary and the following text is read again as the next game trees.
+// Values closed before the end of the input are kept as they are, even if they contain such a boundary.
+func readGameTree(pr *positionReader, doRepair bool) (*rawGameTree, error) {
+	// spin to the tree start
+	for {
+		currRune, _, err := pr.ReadRune()
+		if err != nil {
+			return nil, err
+		}
+		if currRune == structures.GameTreeStart {
+			break
+		}
+	}
+
+	raw := &rawGameTree{start: pr.last}
+	var text strings.Builder
+	text.WriteRune(structures.GameTreeStart)
+
+	depth := 1
+	inValue, doEscape := false, false
+	// valuePos is the position of the current value
+	var valuePos position
+	var valueBoundary boundary
+
+	for depth > 0 {
+		currRune, _, err := pr.ReadRune()
+		if err != nil {
+			if err != io.EOF || !doRepair {
+				raw.text = text.String()
+				return raw, newSyntaxError(pr, GameTreeElement, err, "unterminated game tree")
+			}
+
+			output := text.String()
+			if inValue && valueBoundary.state == 3 {
+				b := valueBoundary
+				// give the text from the start of the next game tree back
+				pr.pushBack(output[b.next:], b.nextPos)
+
+				output = output[:b.end] + string(structures.PropertyValueEnd) + strings.Repeat(string(structures.GameTreeEnd), depth)
+				raw.repairs = append(raw.repairs, repair{valuePos, fmt.Sprintf("closed unterminated value at the end of the game tree at line %d, column %d", b.endPos.Line, b.endPos.Column)})
+				if depth > 1 {
+					raw.repairs = append(raw.repairs, repair{b.endPos, fmt.Sprintf("added %d missing '%c'", depth-1, structures.GameTreeEnd)})
+				}
+
+				text.Reset()
+				text.WriteString(output)
+				break
+			}
+			if inValue {
+				// a dangling escape char would escape the closing bracket
+				if doEscape {
+					output = strings.TrimSuffix(output, `\`)
+				}
+				output += string(structures.PropertyValueEnd)
+				raw.repairs = append(raw.repairs, repair{valuePos, "closed unterminated value"})
+			}
+			output += strings.Repeat(string(structures.GameTreeEnd), depth)
+			raw.repairs = append(raw.repairs, repair{pr.last, fmt.Sprintf("added %d missing '%c'", depth, structures.GameTreeEnd)})
+
+			text.Reset()
+			text.WriteString(output)
+			break
+		}
+
+		text.WriteRune(currRune)
+
+		switch {
+		case inValue && doEscape:
+			doEscape = false
+			valueBoundary.escape()
+		case inValue && currRune == '\\':
+			doEscape = true
+		case inValue && currRune == structures.PropertyValueEnd:
+			inValue = false
+		case inValue:
+			if doRepair {
+				valueBoundary.add(currRune, text.Len()-utf8.RuneLen(currRune), pr.last)
+			}
+		case currRune == structures.PropertyValueStart:
+			inValue = true
+			valuePos, valueBoundary = pr.last, boundary{}
+		case currRune == structures.GameTreeStart:
+			depth++
+		case currRune == structures.GameTreeEnd:
+			depth--
+		}
+	}
+
+	raw.text = text.String()
+	return raw, nil
+}
+
+// nodeSteps returns the path (see structures.Cursor.Path) of the node, which contains the byte offset in the text of a
+// top level game tree. Offsets before the first node belong to the root node.
+func nodeSteps(text string, offset int) []int {
+	// frame is an open game tree: the length of steps at its start and the number of its variations so far
+	type frame struct {
+		length     int
+		variations int
+	}
+
+	var steps []int
+	var stack []frame
+	// variation is the index of the variation started by the next node or -1 if the node continues the sequence
+	variation := -1
+	hasRoot := false
+	inValue, doEscape := false, false
+
+	for i, currRune := range text {
+		if i >= offset {
+			break
+		}
+
+		switch {
+		case inValue && doEscape:
+			doEscape = false
+		case inValue && currRune == '\\':
+			doEscape = true
+		case inValue && currRune == structures.PropertyValueEnd:
+			inValue = false
+		case inValue:
+		case currRune == structures.PropertyValueStart:
+			inValue = true
+		case currRune == structures.GameTreeStart:
+			if len(stack) > 0 {
+				variation = stack[len(stack)-1].variations
+				stack[len(stack)-1].variations++
+			}
+			stack = append(stack, frame{length: len(steps)})
+		case currRune == structures.GameTreeEnd && len(stack) > 0:
+			steps = steps[:stack[len(stack)-1].length]
+			stack = stack[:len(stack)-1]
+			variation = -1
+		case currRune == structures.NodeSeparator:
+			switch {
+			case !hasRoot:
+				hasRoot = true
+			case variation >= 0:
+				steps = append(steps, variation)
+				variation = -1
+			default:
+				steps = append(steps, 0)
+			}
+		}
+	}
+	return steps
+}
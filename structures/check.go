@@ -0,0 +1,60 @@
+package structures
+
+import (
+	"errors"
+	"fmt"
+)
+
+var MixedMoveSetupError = errors.New("Move and setup properties in the same node")
+var MisplacedRootPropertyError = errors.New("Root property outside the root node")
+
+// Check verifies the FF[4] rules for property categories within the node:
+// move and setup properties must not be mixed and root properties may appear only in the root node.
+// All violations are returned joined (see errors.Join). Use errors.Is to tell them apart.
+func (node Node) Check(isRoot bool) error {
+	var errs []error
+	var moveIdent, setupIdent PropIdent
+
+	for _, prop := range node.Properties {
+		switch prop.Type() {
+		case MoveProperty:
+			if moveIdent == "" {
+				moveIdent = prop.Ident
+			}
+		case SetupProperty:
+			if setupIdent == "" {
+				setupIdent = prop.Ident
+			}
+		case RootProperty:
+			if !isRoot {
+				errs = append(errs, fmt.Errorf("%w: %s", MisplacedRootPropertyError, prop.Ident))
+			}
+		}
+	}
+
+	if moveIdent != "" && setupIdent != "" {
+		errs = append(errs, fmt.Errorf("%w: %s and %s", MixedMoveSetupError, moveIdent, setupIdent))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Check runs Node.Check for every node in the tree and its children. The first node of a tree without parent is
+// considered to be the root node.
+func (tree *GameTree) Check() error {
+	var errs []error
+
+	for i, node := range tree.Sequence.Nodes {
+		if err := node.Check(tree.Parent == nil && i == 0); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, child := range tree.Children {
+		if err := child.Check(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
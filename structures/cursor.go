@@ -0,0 +1,151 @@
+package structures
+
+import "slices"
+
+// Cursor walks a game tree node by node. It hides the split between GameTree and Sequence - the nodes are treated as
+// an ordinary tree, where the last node of a sequence has the first nodes of the child game trees as children.
+type Cursor struct {
+	tree  *GameTree
+	index int
+}
+
+// NewCursor creates a Cursor pointing to the first node of the tree. It returns nil if the tree has no nodes.
+func NewCursor(tree *GameTree) *Cursor {
+	if tree == nil || len(tree.Sequence.Nodes) == 0 {
+		return nil
+	}
+	return &Cursor{tree: tree}
+}
+
+// Node returns the current node. Changes made through the pointer are made to the tree.
+func (c *Cursor) Node() *Node {
+	return &c.tree.Sequence.Nodes[c.index]
+}
+
+// Tree returns the game tree holding the current node
+func (c *Cursor) Tree() *GameTree {
+	return c.tree
+}
+
+// Index returns the position of the current node within the sequence of its game tree
+func (c *Cursor) Index() int {
+	return c.index
+}
+
+// children returns the positions of the child nodes of the current node
+func (c *Cursor) children() []Cursor {
+	if c.index < len(c.tree.Sequence.Nodes)-1 {
+		return []Cursor{{tree: c.tree, index: c.index + 1}}
+	}
+
+	var children []Cursor
+	for _, child := range c.tree.Children {
+		if len(child.Sequence.Nodes) > 0 {
+			children = append(children, Cursor{tree: child})
+		}
+	}
+	return children
+}
+
+// parent returns the position of the parent node
+func (c *Cursor) parent() (Cursor, bool) {
+	if c.index > 0 {
+		return Cursor{tree: c.tree, index: c.index - 1}, true
+	}
+	if c.tree.Parent != nil && len(c.tree.Parent.Sequence.Nodes) > 0 {
+		return Cursor{tree: c.tree.Parent, index: len(c.tree.Parent.Sequence.Nodes) - 1}, true
+	}
+	return Cursor{}, false
+}
+
+// Variations returns the number of child nodes of the current node
+func (c *Cursor) Variations() int {
+	return len(c.children())
+}
+
+// Next moves to the given child of the current node. Variation 0 is the main line.
+// It returns false and does not move if there is no such child.
+func (c *Cursor) Next(variation int) bool {
+	children := c.children()
+	if variation < 0 || variation >= len(children) {
+		return false
+	}
+	*c = children[variation]
+	return true
+}
+
+// Prev moves to the parent of the current node. It returns false and does not move if the cursor is at the root.
+func (c *Cursor) Prev() bool {
+	parent, ok := c.parent()
+	if ok {
+		*c = parent
+	}
+	return ok
+}
+
+// Up moves to the closest branch point above the current node, i.e. to the closest ancestor with more than one child.
+// It returns false and does not move if there is no such node.
+func (c *Cursor) Up() bool {
+	current := *c
+	for current.Prev() {
+		if current.Variations() > 1 {
+			*c = current
+			return true
+		}
+	}
+	return false
+}
+
+// ToRoot moves to the first node of the top level game tree
+func (c *Cursor) ToRoot() {
+	for c.Prev() {
+	}
+}
+
+// ToEnd follows the main line from the current node to its last node
+func (c *Cursor) ToEnd() {
+	for c.Next(0) {
+	}
+}
+
+// Siblings returns the children of the parent node (including the current one) and the index of the current node
+// among them. At the root only the root node itself is returned.
+func (c *Cursor) Siblings() ([]*Node, int) {
+	parent, ok := c.parent()
+	if !ok {
+		return []*Node{c.Node()}, 0
+	}
+
+	var siblings []*Node
+	current := 0
+	for i, sibling := range parent.children() {
+		if sibling == *c {
+			current = i
+		}
+		siblings = append(siblings, sibling.Node())
+	}
+	return siblings, current
+}
+
+// Depth returns the number of nodes between the root and the current node. The root is at depth 0.
+func (c *Cursor) Depth() int {
+	return len(c.Path())
+}
+
+// Path returns the variation taken at each node from the root to the current node, i.e. the arguments for Next
+// which lead from the root to the current node.
+func (c *Cursor) Path() []int {
+	var path []int
+	current := *c
+	for {
+		_, variation := current.Siblings()
+		if !current.Prev() {
+			break
+		}
+		path = append(path, variation)
+	}
+
+	// the path was collected from the current node up
+	slices.Reverse(path)
+	return path
+}
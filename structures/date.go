@@ -0,0 +1,173 @@
+package structures
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date is a partial date of the DT property: only the year, the year and month or a full date.
+// Month and Day are 0 if they are not given.
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// String formats the date as "YYYY", "YYYY-MM" or "YYYY-MM-DD"
+func (date Date) String() string {
+	switch {
+	case date.Month == 0:
+		return fmt.Sprintf("%04d", date.Year)
+	case date.Day == 0:
+		return fmt.Sprintf("%04d-%02d", date.Year, date.Month)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", date.Year, date.Month, date.Day)
+}
+
+// Compare returns -1, 0 or 1 if the date is before, equal to or after the other date. A partial date is before the
+// more precise dates within it, e.g. 1996 < 1996-05 < 1996-05-06.
+func (date Date) Compare(other Date) int {
+	return cmp.Or(cmp.Compare(date.Year, other.Year), cmp.Compare(date.Month, other.Month), cmp.Compare(date.Day, other.Day))
+}
+
+// DateError describes a malformed item of a DT value
+type DateError struct {
+	// Value is the whole DT value
+	Value PropValue
+	// Item is the malformed date and Index its position in the list
+	Item  string
+	Index int
+	Msg   string
+}
+
+func (e *DateError) Error() string {
+	return fmt.Sprintf("DT[%s]: date %d (%q): %s", e.Value, e.Index+1, e.Item, e.Msg)
+}
+
+// DateList is the sorted list of dates of a DT property
+type DateList []Date
+
+// ParseDates parses a DT value as defined in FF[4]: a comma separated list of partial dates ("YYYY", "YYYY-MM",
+// "YYYY-MM-DD"), which may be shortened by leaving out the parts equal to the preceding date. "MM-DD" may follow a
+// full date, "DD" a full date and "MM" a "YYYY-MM" date, e.g. "1996-05-06,07,08" or "1996-12-27,28,1997-01-03".
+// The dates are returned sorted and without duplicates. Malformed dates are reported as DateError.
+func ParseDates(value PropValue) (DateList, error) {
+	var dates DateList
+	var previous Date
+
+	for i, item := range strings.Split(string(value), ",") {
+		item = strings.TrimSpace(item)
+		date, err := parseDate(item, previous, i > 0)
+		if err != nil {
+			return nil, &DateError{Value: value, Item: item, Index: i, Msg: err.Error()}
+		}
+		dates = append(dates, date)
+		previous = date
+	}
+
+	slices.SortFunc(dates, Date.Compare)
+	return slices.Compact(dates), nil
+}
+
+// parseDate parses a single item of a DT list. previous is the preceding date, used for shortened items.
+func parseDate(item string, previous Date, hasPrevious bool) (Date, error) {
+	if item == "" {
+		return Date{}, fmt.Errorf("empty date")
+	}
+
+	parts := strings.Split(item, "-")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		width := 2
+		if i == 0 && len(part) == 4 {
+			width = 4
+		}
+		if len(part) != width || !isDigits(part) {
+			return Date{}, fmt.Errorf("expected YYYY-MM-DD or a shortened form")
+		}
+		numbers[i], _ = strconv.Atoi(part)
+		// everything but the year is a month or a day
+		if numbers[i] == 0 && width == 2 {
+			return Date{}, fmt.Errorf("month and day must not be 00")
+		}
+	}
+
+	var date Date
+	switch {
+	case len(parts[0]) == 4 && len(parts) <= 3:
+		date.Year = numbers[0]
+		if len(numbers) > 1 {
+			date.Month = numbers[1]
+		}
+		if len(numbers) > 2 {
+			date.Day = numbers[2]
+		}
+	case len(parts[0]) == 4 || len(parts) > 2:
+		return Date{}, fmt.Errorf("expected YYYY-MM-DD or a shortened form")
+	case !hasPrevious || previous.Month == 0:
+		return Date{}, fmt.Errorf("a shortened date must follow a date with a month")
+	case len(parts) == 2 && previous.Day != 0:
+		date = Date{Year: previous.Year, Month: numbers[0], Day: numbers[1]}
+	case len(parts) == 1 && previous.Day != 0:
+		date = Date{Year: previous.Year, Month: previous.Month, Day: numbers[0]}
+	case len(parts) == 1:
+		date = Date{Year: previous.Year, Month: numbers[0]}
+	default:
+		return Date{}, fmt.Errorf("MM-DD must follow a full date")
+	}
+
+	if date.Month > 12 {
+		return Date{}, fmt.Errorf("invalid month %02d", date.Month)
+	}
+	if date.Day > daysIn(date.Year, date.Month) {
+		return Date{}, fmt.Errorf("invalid day %02d", date.Day)
+	}
+	return date, nil
+}
+
+func daysIn(year, month int) int {
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// String formats the dates in the shortest form allowed by FF[4] (see ParseDates)
+func (dates DateList) String() string {
+	var output strings.Builder
+
+	for i, date := range dates {
+		if i > 0 {
+			output.WriteString(",")
+		}
+
+		var previous Date
+		if i > 0 {
+			previous = dates[i-1]
+		}
+		switch {
+		case i == 0 || date.Year != previous.Year:
+			output.WriteString(date.String())
+		case previous.Day != 0 && date.Day != 0 && date.Month == previous.Month:
+			fmt.Fprintf(&output, "%02d", date.Day)
+		case previous.Day != 0 && date.Day != 0:
+			fmt.Fprintf(&output, "%02d-%02d", date.Month, date.Day)
+		case previous.Day == 0 && previous.Month != 0 && date.Day == 0 && date.Month != 0:
+			fmt.Fprintf(&output, "%02d", date.Month)
+		default:
+			output.WriteString(date.String())
+		}
+	}
+	return output.String()
+}
+
+// PropValue returns the dates as DT value
+func (dates DateList) PropValue() PropValue {
+	return PropValue(dates.String())
+}
+
+// Compare orders date lists by their first date, then by the following ones. An empty list is first.
+func (dates DateList) Compare(other DateList) int {
+	return slices.CompareFunc(dates, other, Date.Compare)
+}
@@ -0,0 +1,191 @@
+package structures
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var NodeIndexError = errors.New("Node index out of range")
+var VariationIndexError = errors.New("Variation index out of range")
+var DeleteRootError = errors.New("The root node of a top level game tree can not be deleted")
+
+// The editing methods below address nodes by their index in the sequence of the game tree (see Cursor.Tree and
+// Cursor.Index). They keep the Parent of every game tree pointing to the tree holding it in its Children. Cursors and
+// node pointers into the changed trees may become invalid.
+
+func (tree *GameTree) checkIndex(index int) error {
+	if index < 0 || index >= len(tree.Sequence.Nodes) {
+		return fmt.Errorf("%w: %d", NodeIndexError, index)
+	}
+	return nil
+}
+
+func (tree *GameTree) checkVariation(variation int) error {
+	if variation < 0 || variation >= len(tree.Children) {
+		return fmt.Errorf("%w: %d", VariationIndexError, variation)
+	}
+	return nil
+}
+
+// setChildren replaces the children of the tree and points them to it
+func (tree *GameTree) setChildren(children []*GameTree) {
+	tree.Children = children
+	for _, child := range children {
+		child.Parent = tree
+	}
+}
+
+// InsertAfter inserts the node after the node at index. The following nodes and variations become its continuation.
+func (tree *GameTree) InsertAfter(index int, node Node) error {
+	if err := tree.checkIndex(index); err != nil {
+		return err
+	}
+	tree.Sequence.Nodes = slices.Insert(tree.Sequence.Nodes, index+1, node)
+	return nil
+}
+
+// Split moves the nodes after index into a new game tree, which becomes the only child of the tree and takes over its
+// children. The node at index becomes the last node of the sequence, so that variations can be added after it.
+// If the node is already the last one, the tree is left unchanged and nil is returned.
+func (tree *GameTree) Split(index int) (*GameTree, error) {
+	if err := tree.checkIndex(index); err != nil {
+		return nil, err
+	}
+	if index == len(tree.Sequence.Nodes)-1 {
+		return nil, nil
+	}
+
+	rest := &GameTree{Sequence: Sequence{Nodes: slices.Clone(tree.Sequence.Nodes[index+1:])}}
+	rest.setChildren(tree.Children)
+
+	tree.Sequence.Nodes = slices.Clip(tree.Sequence.Nodes[:index+1])
+	tree.setChildren([]*GameTree{rest})
+	return rest, nil
+}
+
+// AddVariation adds the nodes as a new variation after the node at index, splitting the sequence if needed. The new
+// variation is added after the existing ones. If the node has no continuation at all, the nodes are appended to the
+// sequence instead. It returns the game tree holding the added nodes.
+func (tree *GameTree) AddVariation(index int, nodes ...Node) (*GameTree, error) {
+	if len(nodes) == 0 {
+		return nil, EmptyGameTreeError
+	}
+	if err := tree.checkIndex(index); err != nil {
+		return nil, err
+	}
+
+	if index == len(tree.Sequence.Nodes)-1 && len(tree.Children) == 0 {
+		tree.Sequence.Nodes = append(tree.Sequence.Nodes, nodes...)
+		return tree, nil
+	}
+
+	if _, err := tree.Split(index); err != nil {
+		return nil, err
+	}
+	variation := &GameTree{Parent: tree, Sequence: Sequence{Nodes: slices.Clone(nodes)}}
+	tree.Children = append(tree.Children, variation)
+	return variation, nil
+}
+
+// AttachVariation adds the game tree with all its variations as a new variation after the node at index, like
+// AddVariation. If the node has no continuation, the nodes and variations of the game tree are appended to the tree
+// instead. The attached tree must not be part of another tree.
+func (tree *GameTree) AttachVariation(index int, variation *GameTree) error {
+	if len(variation.Sequence.Nodes) == 0 {
+		return EmptyGameTreeError
+	}
+	if err := tree.checkIndex(index); err != nil {
+		return err
+	}
+
+	if index == len(tree.Sequence.Nodes)-1 && len(tree.Children) == 0 {
+		tree.Sequence.Nodes = append(tree.Sequence.Nodes, variation.Sequence.Nodes...)
+		tree.setChildren(variation.Children)
+		return nil
+	}
+
+	if _, err := tree.Split(index); err != nil {
+		return err
+	}
+	variation.Parent = tree
+	tree.Children = append(tree.Children, variation)
+	return nil
+}
+
+// DeleteSubtree deletes the node at index together with all nodes following it. Deleting the first node of a
+// variation removes the variation from its parent. The root node of a top level game tree can not be deleted.
+func (tree *GameTree) DeleteSubtree(index int) error {
+	if err := tree.checkIndex(index); err != nil {
+		return err
+	}
+
+	if index > 0 {
+		tree.Sequence.Nodes = slices.Clip(tree.Sequence.Nodes[:index])
+		for _, child := range tree.Children {
+			child.Parent = nil
+		}
+		tree.Children = nil
+		return nil
+	}
+
+	if tree.Parent == nil {
+		return DeleteRootError
+	}
+	parent := tree.Parent
+	parent.Children = slices.DeleteFunc(parent.Children, func(child *GameTree) bool {
+		return child == tree
+	})
+	tree.Parent = nil
+	return nil
+}
+
+// Promote makes the tree the first variation of its parent. It does nothing for a top level game tree.
+func (tree *GameTree) Promote() {
+	if tree.Parent == nil {
+		return
+	}
+	index := slices.Index(tree.Parent.Children, tree)
+	tree.Parent.MoveChild(index, 0)
+}
+
+// PromoteToMainLine promotes the tree and all its ancestors, so that the tree becomes part of the main line
+func (tree *GameTree) PromoteToMainLine() {
+	for current := tree; current.Parent != nil; current = current.Parent {
+		current.Promote()
+	}
+}
+
+// MoveChild moves the variation at index from to index to, shifting the variations in between
+func (tree *GameTree) MoveChild(from, to int) error {
+	if err := tree.checkVariation(from); err != nil {
+		return err
+	}
+	if err := tree.checkVariation(to); err != nil {
+		return err
+	}
+
+	child := tree.Children[from]
+	children := slices.Delete(tree.Children, from, from+1)
+	tree.Children = slices.Insert(children, to, child)
+	return nil
+}
+
+// MergeSingleChildren appends the nodes of every game tree, which is the only child of its parent, to the sequence of
+// the parent in the whole subtree, e.g. "(;B[aa](;W[bb](;B[cc])))" becomes "(;B[aa];W[bb];B[cc])".
+// It returns the number of merged game trees.
+func (tree *GameTree) MergeSingleChildren() int {
+	merged := 0
+	for len(tree.Children) == 1 {
+		child := tree.Children[0]
+		tree.Sequence.Nodes = append(tree.Sequence.Nodes, child.Sequence.Nodes...)
+		tree.setChildren(child.Children)
+		child.Parent, child.Children = nil, nil
+		merged++
+	}
+
+	for _, child := range tree.Children {
+		merged += child.MergeSingleChildren()
+	}
+	return merged
+}
@@ -0,0 +1,141 @@
+package structures
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// EqualOptions configure the comparison made by the Equal methods
+type EqualOptions struct {
+	// IgnorePropertyOrder compares the properties of nodes regardless of their order
+	IgnorePropertyOrder bool
+	// IgnoreValueOrder compares the values of list properties (e.g. AB[aa][bb] and AB[bb][aa]) regardless of their order
+	IgnoreValueOrder bool
+	// IgnoreWhitespace compares values with all runs of white space (including line breaks) treated as a single
+	// space and leading and trailing white space removed, e.g. values parsed with and without normalization
+	IgnoreWhitespace bool
+}
+
+// Clone returns a deep copy of the property
+func (prop Property) Clone() Property {
+	prop.Values = slices.Clone(prop.Values)
+	prop.Raw = slices.Clone(prop.Raw)
+	return prop
+}
+
+// Equal reports whether both properties have the same identifier and values. Raw values are not compared.
+func (prop Property) Equal(other Property, options EqualOptions) bool {
+	if prop.Ident != other.Ident || len(prop.Values) != len(other.Values) {
+		return false
+	}
+
+	values, otherValues := prop.comparableValues(options), other.comparableValues(options)
+	if options.IgnoreValueOrder {
+		slices.Sort(values)
+		slices.Sort(otherValues)
+	}
+	return slices.Equal(values, otherValues)
+}
+
+func (prop Property) comparableValues(options EqualOptions) []PropValue {
+	values := slices.Clone(prop.Values)
+	if options.IgnoreWhitespace {
+		for i, value := range values {
+			values[i] = PropValue(strings.Join(strings.Fields(string(value)), " "))
+		}
+	}
+	return values
+}
+
+// Clone returns a deep copy of the node
+func (node Node) Clone() Node {
+	properties := make([]Property, 0, len(node.Properties))
+	for _, prop := range node.Properties {
+		properties = append(properties, prop.Clone())
+	}
+	node.Properties = properties
+	return node
+}
+
+// Equal reports whether both nodes have equal properties (see Property.Equal). The Id is not compared.
+func (node Node) Equal(other Node, options EqualOptions) bool {
+	if len(node.Properties) != len(other.Properties) {
+		return false
+	}
+
+	properties, otherProperties := node.Properties, other.Properties
+	if options.IgnorePropertyOrder {
+		properties, otherProperties = sortedProperties(properties), sortedProperties(otherProperties)
+	}
+
+	for i, prop := range properties {
+		if !prop.Equal(otherProperties[i], options) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedProperties(properties []Property) []Property {
+	sorted := slices.Clone(properties)
+	slices.SortStableFunc(sorted, func(a, b Property) int {
+		return cmp.Compare(a.Ident, b.Ident)
+	})
+	return sorted
+}
+
+// Clone returns a deep copy of the tree and its children. The copy is a top level tree - its Parent is nil.
+func (tree *GameTree) Clone() *GameTree {
+	clone := &GameTree{Sequence: Sequence{Nodes: make([]Node, 0, len(tree.Sequence.Nodes))}}
+	for _, node := range tree.Sequence.Nodes {
+		clone.Sequence.Nodes = append(clone.Sequence.Nodes, node.Clone())
+	}
+
+	for _, child := range tree.Children {
+		childClone := child.Clone()
+		childClone.Parent = clone
+		clone.Children = append(clone.Children, childClone)
+	}
+	return clone
+}
+
+// Equal reports whether both trees have equal nodes (see Node.Equal) and equal variations in the same order.
+// The parents of the trees are not compared.
+func (tree *GameTree) Equal(other *GameTree, options EqualOptions) bool {
+	if tree == nil || other == nil {
+		return tree == other
+	}
+	if len(tree.Sequence.Nodes) != len(other.Sequence.Nodes) || len(tree.Children) != len(other.Children) {
+		return false
+	}
+
+	for i, node := range tree.Sequence.Nodes {
+		if !node.Equal(other.Sequence.Nodes[i], options) {
+			return false
+		}
+	}
+	for i, child := range tree.Children {
+		if !child.Equal(other.Children[i], options) {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a deep copy of the collection
+func (collection Collection) Clone() *Collection {
+	clone := &Collection{Charset: collection.Charset}
+	for _, tree := range collection.GameTrees {
+		clone.GameTrees = append(clone.GameTrees, tree.Clone())
+	}
+	return clone
+}
+
+// Equal reports whether both collections hold equal game trees (see GameTree.Equal) in the same order.
+// The charsets are not compared.
+func (collection Collection) Equal(other Collection, options EqualOptions) bool {
+	return slices.EqualFunc(collection.GameTrees, other.GameTrees, func(tree, otherTree *GameTree) bool {
+		return tree.Equal(otherTree, options)
+	})
+}
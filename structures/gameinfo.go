@@ -0,0 +1,104 @@
+package structures
+
+import (
+	"errors"
+)
+
+// GameInfo holds the typed values of the game-info properties
+type GameInfo struct {
+	// BlackPlayer and WhitePlayer are the names of the players (PB, PW)
+	BlackPlayer string
+	WhitePlayer string
+	// BlackRank and WhiteRank are the ranks of the players (BR, WR)
+	BlackRank string
+	WhiteRank string
+	// Result is the outcome of the game (RE)
+	Result Result
+	// Dates are the dates the game was played on (DT)
+	Dates DateList
+	// Komi (KM)
+	Komi float64
+	// Handicap is the number of handicap stones (HA)
+	Handicap int
+	// Rules (RU)
+	Rules string
+	// Event is the name of the tournament (EV)
+	Event string
+	// Round (RO)
+	Round string
+	// Place where the game was played (PC)
+	Place string
+}
+
+// Info returns the game-info of the tree. It's read from the first node of the main line, which holds game-info
+// properties - usually the root node. Values which can not be converted are left empty and their errors (ValueError,
+// DateError) are returned joined with errors.Join.
+func (tree *GameTree) Info() (GameInfo, error) {
+	var info GameInfo
+	var errs []error
+
+	// walk the main line with a cursor - MainLine would copy every node
+	var node *Node
+	for cursor := NewCursor(tree); cursor != nil; {
+		if hasGameInfo(*cursor.Node()) {
+			node = cursor.Node()
+			break
+		}
+		if !cursor.Next(0) {
+			break
+		}
+	}
+	if node == nil {
+		return info, nil
+	}
+
+	for _, prop := range node.Properties {
+		var err error
+		switch prop.Ident {
+		case "PB":
+			info.BlackPlayer, err = prop.AsText()
+		case "PW":
+			info.WhitePlayer, err = prop.AsText()
+		case "BR":
+			info.BlackRank, err = prop.AsText()
+		case "WR":
+			info.WhiteRank, err = prop.AsText()
+		case "RE":
+			var value string
+			if value, err = prop.AsText(); err == nil {
+				info.Result, err = ParseResult(PropValue(value))
+			}
+		case "DT":
+			var value string
+			if value, err = prop.AsText(); err == nil {
+				info.Dates, err = ParseDates(PropValue(value))
+			}
+		case "KM":
+			info.Komi, err = prop.AsReal()
+		case "HA":
+			info.Handicap, err = prop.AsNumber()
+		case "RU":
+			info.Rules, err = prop.AsText()
+		case "EV":
+			info.Event, err = prop.AsText()
+		case "RO":
+			info.Round, err = prop.AsText()
+		case "PC":
+			info.Place, err = prop.AsText()
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return info, errors.Join(errs...)
+}
+
+func hasGameInfo(node Node) bool {
+	for _, prop := range node.Properties {
+		if prop.Type() == GameInfoProperty {
+			return true
+		}
+	}
+	return false
+}
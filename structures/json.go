@@ -0,0 +1,147 @@
+package structures
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+)
+
+// The JSON representation of a collection keeps the order of the game trees, nodes, properties and values. The
+// parent of a game tree is implied by the nesting and set again when decoding:
+//
+//	{
+//	  "charset": "ISO-8859-1",
+//	  "gameTrees": [
+//	    {
+//	      "nodes": [
+//	        {"id": 1, "properties": [{"ident": "FF", "values": ["4"]}, {"ident": "AP", "values": ["Prim:iview:3.1"], "raw": ["Prim\\:iview:3.1"]}]},
+//	        {"id": 2, "properties": [{"ident": "B", "values": ["pd"]}]}
+//	      ],
+//	      "variations": [
+//	        {"nodes": [{"id": 3, "properties": [{"ident": "W", "values": ["dp"]}]}]},
+//	        {"nodes": [{"id": 4, "properties": [{"ident": "W", "values": ["dd"]}]}]}
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// "charset" (see Collection.Charset), "variations" and "id" (see Node.Id) are omitted when empty. "raw" (see
+// Property.Raw) is written only if the values alone would be written back to SGF differently (e.g. escaped colons in
+// composed values).
+// Every game tree must have at least one node (null is a game tree without nodes) and every property an identifier.
+
+var EmptyGameTreeError = errors.New("Game tree without nodes")
+var EmptyPropIdentError = errors.New("Property without identifier")
+
+type jsonCollection struct {
+	Charset   string      `json:"charset,omitempty"`
+	GameTrees []*GameTree `json:"gameTrees"`
+}
+
+type jsonGameTree struct {
+	Nodes      []Node      `json:"nodes"`
+	Variations []*GameTree `json:"variations,omitempty"`
+}
+
+type jsonNode struct {
+	Id         int        `json:"id,omitempty"`
+	Properties []Property `json:"properties"`
+}
+
+type jsonProperty struct {
+	Ident  PropIdent   `json:"ident"`
+	Values []PropValue `json:"values"`
+	Raw    []PropValue `json:"raw,omitempty"`
+}
+
+func (collection Collection) MarshalJSON() ([]byte, error) {
+	trees := collection.GameTrees
+	if trees == nil {
+		trees = []*GameTree{}
+	}
+	return json.Marshal(jsonCollection{Charset: collection.Charset, GameTrees: trees})
+}
+
+func (collection *Collection) UnmarshalJSON(data []byte) error {
+	var decoded jsonCollection
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if slices.Contains(decoded.GameTrees, nil) {
+		return EmptyGameTreeError
+	}
+	collection.Charset = decoded.Charset
+	collection.GameTrees = decoded.GameTrees
+	return nil
+}
+
+func (tree GameTree) MarshalJSON() ([]byte, error) {
+	nodes := tree.Sequence.Nodes
+	if nodes == nil {
+		nodes = []Node{}
+	}
+	return json.Marshal(jsonGameTree{Nodes: nodes, Variations: tree.Children})
+}
+
+// UnmarshalJSON decodes the game tree and sets the Parent of its variations. The Parent of the tree itself is left
+// unchanged.
+func (tree *GameTree) UnmarshalJSON(data []byte) error {
+	var decoded jsonGameTree
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if len(decoded.Nodes) == 0 || slices.Contains(decoded.Variations, nil) {
+		return EmptyGameTreeError
+	}
+
+	tree.Sequence = Sequence{Nodes: decoded.Nodes}
+	tree.Children = decoded.Variations
+	for _, child := range tree.Children {
+		child.Parent = tree
+	}
+	return nil
+}
+
+func (node Node) MarshalJSON() ([]byte, error) {
+	properties := node.Properties
+	if properties == nil {
+		properties = []Property{}
+	}
+	return json.Marshal(jsonNode{Id: node.Id, Properties: properties})
+}
+
+func (node *Node) UnmarshalJSON(data []byte) error {
+	var decoded jsonNode
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	node.Id = decoded.Id
+	node.Properties = decoded.Properties
+	return nil
+}
+
+func (prop Property) MarshalJSON() ([]byte, error) {
+	values := prop.Values
+	if values == nil {
+		values = []PropValue{}
+	}
+	encoded := jsonProperty{Ident: prop.Ident, Values: values}
+	// the raw values are needed only if the values would be written differently without them
+	if !slices.Equal(prop.EscapedValues(), Property{Ident: prop.Ident, Values: prop.Values}.EscapedValues()) {
+		encoded.Raw = prop.Raw
+	}
+	return json.Marshal(encoded)
+}
+
+func (prop *Property) UnmarshalJSON(data []byte) error {
+	var decoded jsonProperty
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Ident == "" {
+		return EmptyPropIdentError
+	}
+
+	*prop = Property{Ident: decoded.Ident, Values: decoded.Values, Raw: decoded.Raw}
+	return nil
+}
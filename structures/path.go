@@ -0,0 +1,186 @@
+package structures
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var PathError = errors.New("Invalid node path")
+
+// FormatPath formats the path of a node: the index of the game tree, followed by the number of moves from the root
+// along the first variations. Every time another variation is taken, "-", the variation index and the number of moves
+// in it follow. E.g. "0.12-2.5" is the node reached from the root of the first tree by 12 moves along the main line,
+// then taking the third variation and 4 more moves along its first variations. The root node is "0.0".
+//
+// steps holds the variation taken in each move (see Cursor.Path).
+func FormatPath(tree int, steps []int) string {
+	var output strings.Builder
+	output.WriteString(strconv.Itoa(tree))
+
+	count := 0
+	for _, step := range steps {
+		if step == 0 {
+			count++
+			continue
+		}
+		fmt.Fprintf(&output, ".%d-%d", count, step)
+		count = 1
+	}
+	fmt.Fprintf(&output, ".%d", count)
+	return output.String()
+}
+
+// ParsePath parses a path in the format of FormatPath and returns the index of the game tree and the variation taken
+// in each move. Only canonical paths are accepted, e.g. "0.2" but not "0.1-0.1".
+func ParsePath(path string) (int, []int, error) {
+	var tree int
+	var steps []int
+
+	for i, segment := range strings.Split(path, "-") {
+		first, count, found := strings.Cut(segment, ".")
+		if !found {
+			return 0, nil, fmt.Errorf("%w: %q: expected a dot in %q", PathError, path, segment)
+		}
+		index, ok := parsePathNumber(first)
+		if !ok {
+			return 0, nil, fmt.Errorf("%w: %q: invalid index %q", PathError, path, first)
+		}
+		moves, ok := parsePathNumber(count)
+		if !ok {
+			return 0, nil, fmt.Errorf("%w: %q: invalid number of moves %q", PathError, path, count)
+		}
+
+		if i == 0 {
+			tree = index
+		} else {
+			// the first variation is written as moves along the main line, so "-0" is not canonical
+			if index == 0 {
+				return 0, nil, fmt.Errorf("%w: %q: variation 0 must be counted in the moves before it", PathError, path)
+			}
+			// taking the variation is the first move in it
+			if moves == 0 {
+				return 0, nil, fmt.Errorf("%w: %q: a variation must have at least one move", PathError, path)
+			}
+			steps = append(steps, index)
+			moves--
+		}
+		for ; moves > 0; moves-- {
+			steps = append(steps, 0)
+		}
+	}
+	return tree, steps, nil
+}
+
+// parsePathNumber parses a number of a path as written by FormatPath: only digits and no leading zeros
+func parsePathNumber(text string) (int, bool) {
+	if text == "" || (len(text) > 1 && text[0] == '0') || strings.TrimLeft(text, "0123456789") != "" {
+		return 0, false
+	}
+	number, err := strconv.Atoi(text)
+	return number, err == nil
+}
+
+// CursorAt returns a Cursor pointing to the node at the path (see FormatPath)
+func (collection Collection) CursorAt(path string) (*Cursor, error) {
+	tree, steps, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if tree >= len(collection.GameTrees) {
+		return nil, fmt.Errorf("%w: %q: no game tree %d", PathError, path, tree)
+	}
+
+	cursor := NewCursor(collection.GameTrees[tree])
+	if cursor == nil {
+		return nil, fmt.Errorf("%w: %q: %w", PathError, path, EmptyGameTreeError)
+	}
+	for i, step := range steps {
+		if !cursor.Next(step) {
+			return nil, fmt.Errorf("%w: %q: no variation %d after %s", PathError, path, step, FormatPath(tree, steps[:i]))
+		}
+	}
+	return cursor, nil
+}
+
+// NodeAt returns the node at the path (see FormatPath). Changes made through the pointer are made to the collection.
+func (collection Collection) NodeAt(path string) (*Node, error) {
+	cursor, err := collection.CursorAt(path)
+	if err != nil {
+		return nil, err
+	}
+	return cursor.Node(), nil
+}
+
+// NodeByID returns the node with the Id. It returns false if there is no such node.
+func (collection Collection) NodeByID(id int) (*Node, bool) {
+	_, cursor, found := collection.find(func(node *Node) bool {
+		return node.Id == id
+	})
+	if !found {
+		return nil, false
+	}
+	return cursor.Node(), true
+}
+
+// PathOf returns the path (see FormatPath) of the node. The node must be a part of the collection, e.g. as returned
+// by NodeByID or Cursor.Node - it's found by its address, not by its properties. It returns false if it's not found.
+func (collection Collection) PathOf(node *Node) (string, bool) {
+	tree, cursor, found := collection.find(func(current *Node) bool {
+		return current == node
+	})
+	if !found {
+		return "", false
+	}
+	return FormatPath(tree, cursor.Path()), true
+}
+
+// find returns the index of the game tree and a cursor pointing to the first node in pre-order, which matches
+func (collection Collection) find(match func(node *Node) bool) (int, Cursor, bool) {
+	for i, tree := range collection.GameTrees {
+		root := NewCursor(tree)
+		if root == nil {
+			continue
+		}
+		if cursor, found := findNode(*root, match); found {
+			return i, cursor, true
+		}
+	}
+	return 0, Cursor{}, false
+}
+
+func findNode(c Cursor, match func(node *Node) bool) (Cursor, bool) {
+	if match(c.Node()) {
+		return c, true
+	}
+	for i := 0; i < c.Variations(); i++ {
+		child := c
+		child.Next(i)
+		if found, ok := findNode(child, match); ok {
+			return found, true
+		}
+	}
+	return Cursor{}, false
+}
+
+// AssignIDs numbers the nodes of all game trees in pre-order (the order they are written in), starting with 1
+func (collection Collection) AssignIDs() {
+	next := 1
+	for _, tree := range collection.GameTrees {
+		next = tree.AssignIDs(next)
+	}
+}
+
+// AssignIDs numbers the nodes of the tree and its variations in pre-order, starting with first. It returns the next
+// free number.
+func (tree *GameTree) AssignIDs(first int) int {
+	for i := range tree.Sequence.Nodes {
+		tree.Sequence.Nodes[i].Id = first
+		first++
+	}
+	for _, child := range tree.Children {
+		first = child.AssignIDs(first)
+	}
+	return first
+}
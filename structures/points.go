@@ -0,0 +1,119 @@
+package structures
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ExpandPoints returns the points of a list of Point values. Compressed rectangles (e.g. "do:gq", the upper left and
+// the lower right corner) are expanded into single points. Points contained in more than one value are returned once.
+func ExpandPoints(values []PropValue) ([]Point, error) {
+	var points []Point
+	seen := make(map[Point]bool)
+
+	for _, value := range values {
+		from, to, found := strings.Cut(string(value), ":")
+		if !found {
+			to = from
+		}
+
+		upperLeft, err := ParsePoint(PropValue(from))
+		if err != nil {
+			return nil, err
+		}
+		lowerRight, err := ParsePoint(PropValue(to))
+		if err != nil {
+			return nil, err
+		}
+		if upperLeft.X > lowerRight.X || upperLeft.Y > lowerRight.Y {
+			return nil, fmt.Errorf("invalid rectangle %q", string(value))
+		}
+
+		for y := upperLeft.Y; y <= lowerRight.Y; y++ {
+			for x := upperLeft.X; x <= lowerRight.X; x++ {
+				point := Point{X: x, Y: y}
+				if !seen[point] {
+					seen[point] = true
+					points = append(points, point)
+				}
+			}
+		}
+	}
+	return points, nil
+}
+
+// AsPoints returns the points of a point list property (e.g. AB, AE, TR) with compressed rectangles expanded.
+// An empty list (e.g. VW[]) results in no points.
+func (prop Property) AsPoints() ([]Point, error) {
+	if err := prop.checkConvertible(PointValue, StoneValue); err != nil {
+		return nil, err
+	}
+
+	values := prop.Values
+	if len(values) == 1 && values[0] == "" {
+		values = nil
+	}
+
+	for i, value := range values {
+		if _, err := ExpandPoints(values[i : i+1]); err != nil {
+			return nil, &ValueError{Ident: prop.Ident, Value: value, Index: i, Type: PointValue, Msg: err.Error()}
+		}
+	}
+	return ExpandPoints(values)
+}
+
+// CompressPoints returns values describing the points with as few rectangles as the greedy algorithm finds: starting
+// from the upper left corner each rectangle is extended to the right and then down as far as possible.
+// Single points are written as a point and larger rectangles as "upperleft:lowerright".
+func CompressPoints(points []Point) []PropValue {
+	remaining := make(map[Point]bool)
+	for _, point := range points {
+		remaining[point] = true
+	}
+
+	sorted := slices.Clone(points)
+	slices.SortFunc(sorted, func(a, b Point) int {
+		if a.Y != b.Y {
+			return a.Y - b.Y
+		}
+		return a.X - b.X
+	})
+
+	var values []PropValue
+	for _, start := range sorted {
+		if !remaining[start] {
+			continue
+		}
+
+		end := start
+		for remaining[Point{X: end.X + 1, Y: start.Y}] {
+			end.X++
+		}
+		for rowRemaining(remaining, start.X, end.X, end.Y+1) {
+			end.Y++
+		}
+
+		for y := start.Y; y <= end.Y; y++ {
+			for x := start.X; x <= end.X; x++ {
+				delete(remaining, Point{X: x, Y: y})
+			}
+		}
+
+		if start == end {
+			values = append(values, PropValue(start.String()))
+		} else {
+			values = append(values, PropValue(start.String()+":"+end.String()))
+		}
+	}
+	return values
+}
+
+func rowRemaining(remaining map[Point]bool, fromX, toX, y int) bool {
+	for x := fromX; x <= toX; x++ {
+		if !remaining[Point{X: x, Y: y}] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package structures
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PropertyInfo describes the values a known property can hold
+type PropertyInfo struct {
+	// Type is the type of the property's values
+	Type ValueType
+	// ComposeTypes are the types of both parts of a composed value. They are set for Compose properties and for
+	// properties which may hold either a value of Type or a composed value (e.g. SZ[19] or SZ[19:13]).
+	ComposeTypes [2]ValueType
+	// List is true if the property may hold more than one value
+	List bool
+	// EmptyList is true if the list may be empty, i.e. consist of a single None value (e.g. VW[])
+	EmptyList bool
+	// Category is the property type (move, setup, root, game-info or none)
+	Category PropertyType
+}
+
+func (info PropertyInfo) in(category PropertyType) PropertyInfo {
+	info.Category = category
+	return info
+}
+
+// HasCompose reports whether the property may hold composed values
+func (info PropertyInfo) HasCompose() bool {
+	return info.Type == ComposeValue || info.ComposeTypes != [2]ValueType{}
+}
+
+func single(valueType ValueType) PropertyInfo {
+	return PropertyInfo{Type: valueType}
+}
+
+func list(valueType ValueType) PropertyInfo {
+	return PropertyInfo{Type: valueType, List: true}
+}
+
+func elist(valueType ValueType) PropertyInfo {
+	return PropertyInfo{Type: valueType, List: true, EmptyList: true}
+}
+
+func composed(valueType ValueType, left, right ValueType) PropertyInfo {
+	return PropertyInfo{Type: valueType, ComposeTypes: [2]ValueType{left, right}}
+}
+
+// knownProperties lists the FF[4] properties (general and go specific) with the types of their values
+var knownProperties = map[PropIdent]PropertyInfo{
+	// Move
+	"B":  single(MoveValue).in(MoveProperty),
+	"KO": single(NoneValue).in(MoveProperty),
+	"MN": single(NumberValue).in(MoveProperty),
+	"W":  single(MoveValue).in(MoveProperty),
+	// Setup
+	"AB": list(StoneValue).in(SetupProperty),
+	"AE": list(PointValue).in(SetupProperty),
+	"AW": list(StoneValue).in(SetupProperty),
+	"PL": single(ColorValue).in(SetupProperty),
+	// Node annotation
+	"C":  single(TextValue),
+	"DM": single(DoubleValue),
+	"GB": single(DoubleValue),
+	"GW": single(DoubleValue),
+	"HO": single(DoubleValue),
+	"N":  single(SimpleTextValue),
+	"UC": single(DoubleValue),
+	"V":  single(RealValue),
+	// Move annotation
+	"BM": single(DoubleValue).in(MoveProperty),
+	"DO": single(NoneValue).in(MoveProperty),
+	"IT": single(NoneValue).in(MoveProperty),
+	"TE": single(DoubleValue).in(MoveProperty),
+	// Markup
+	"AR": {Type: ComposeValue, ComposeTypes: [2]ValueType{PointValue, PointValue}, List: true},
+	"CR": list(PointValue),
+	"DD": elist(PointValue),
+	"LB": {Type: ComposeValue, ComposeTypes: [2]ValueType{PointValue, SimpleTextValue}, List: true},
+	"LN": {Type: ComposeValue, ComposeTypes: [2]ValueType{PointValue, PointValue}, List: true},
+	"MA": list(PointValue),
+	"SL": list(PointValue),
+	"SQ": list(PointValue),
+	"TR": list(PointValue),
+	// Root
+	"AP": composed(ComposeValue, SimpleTextValue, SimpleTextValue).in(RootProperty),
+	"CA": single(SimpleTextValue).in(RootProperty),
+	"FF": single(NumberValue).in(RootProperty),
+	"GM": single(NumberValue).in(RootProperty),
+	"ST": single(NumberValue).in(RootProperty),
+	"SZ": composed(NumberValue, NumberValue, NumberValue).in(RootProperty),
+	// Game info
+	"AN": single(SimpleTextValue).in(GameInfoProperty),
+	"BR": single(SimpleTextValue).in(GameInfoProperty),
+	"BT": single(SimpleTextValue).in(GameInfoProperty),
+	"CP": single(SimpleTextValue).in(GameInfoProperty),
+	"DT": single(SimpleTextValue).in(GameInfoProperty),
+	"EV": single(SimpleTextValue).in(GameInfoProperty),
+	"GC": single(TextValue).in(GameInfoProperty),
+	"GN": single(SimpleTextValue).in(GameInfoProperty),
+	"ON": single(SimpleTextValue).in(GameInfoProperty),
+	"OT": single(SimpleTextValue).in(GameInfoProperty),
+	"PB": single(SimpleTextValue).in(GameInfoProperty),
+	"PC": single(SimpleTextValue).in(GameInfoProperty),
+	"PW": single(SimpleTextValue).in(GameInfoProperty),
+	"RE": single(SimpleTextValue).in(GameInfoProperty),
+	"RO": single(SimpleTextValue).in(GameInfoProperty),
+	"RU": single(SimpleTextValue).in(GameInfoProperty),
+	"SO": single(SimpleTextValue).in(GameInfoProperty),
+	"TM": single(RealValue).in(GameInfoProperty),
+	"US": single(SimpleTextValue).in(GameInfoProperty),
+	"WR": single(SimpleTextValue).in(GameInfoProperty),
+	"WT": single(SimpleTextValue).in(GameInfoProperty),
+	// Timing
+	"BL": single(RealValue).in(MoveProperty),
+	"OB": single(NumberValue).in(MoveProperty),
+	"OW": single(NumberValue).in(MoveProperty),
+	"WL": single(RealValue).in(MoveProperty),
+	// Miscellaneous
+	"FG": composed(NoneValue, NumberValue, SimpleTextValue),
+	"PM": single(NumberValue),
+	"VW": elist(PointValue),
+	// Go specific
+	"HA": single(NumberValue).in(GameInfoProperty),
+	"KM": single(RealValue).in(GameInfoProperty),
+	"TB": elist(PointValue),
+	"TW": elist(PointValue),
+}
+
+// LookupProperty returns the description of a known FF[4] property
+func LookupProperty(ident PropIdent) (PropertyInfo, bool) {
+	info, ok := knownProperties[ident]
+	return info, ok
+}
+
+// Check verifies that the values of a known property match its type. Unknown properties are not checked.
+// The first invalid value is reported as ValueError.
+func (prop Property) Check() error {
+	info, ok := LookupProperty(prop.Ident)
+	if !ok {
+		return nil
+	}
+
+	if len(prop.Values) == 0 {
+		return &ValueError{Ident: prop.Ident, Type: info.Type, Msg: "no values"}
+	}
+	if len(prop.Values) > 1 && !info.List {
+		return &ValueError{Ident: prop.Ident, Values: prop.Values, Type: info.Type, Msg: fmt.Sprintf("expected a single value, found %d", len(prop.Values))}
+	}
+	if info.EmptyList && len(prop.Values) == 1 && prop.Values[0] == "" {
+		return nil
+	}
+
+	for i, value := range prop.Values {
+		if !info.accepts(value) {
+			return &ValueError{Ident: prop.Ident, Value: value, Index: i, Type: info.Type, Msg: "value does not match the property type"}
+		}
+	}
+	return nil
+}
+
+// accepts reports whether a single value is valid for the property
+func (info PropertyInfo) accepts(value PropValue) bool {
+	if info.Type != ComposeValue && isValueOfType(value, info.Type) {
+		return true
+	}
+
+	left, right, found := strings.Cut(string(value), ":")
+	if !found {
+		return false
+	}
+
+	if info.HasCompose() {
+		return isValueOfType(PropValue(left), info.ComposeTypes[0]) && isValueOfType(PropValue(right), info.ComposeTypes[1])
+	}
+
+	// lists of points may be compressed to rectangles (e.g. AB[aa:cc])
+	if info.List && (info.Type == PointValue || info.Type == StoneValue) {
+		return isValueOfType(PropValue(left), info.Type) && isValueOfType(PropValue(right), info.Type)
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package structures
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ResultReason tells how a game ended (see Result)
+type ResultReason int
+
+const (
+	// ReasonUnknown - the result is unknown ("?") or the winner is known but not how ("B+")
+	ReasonUnknown ResultReason = iota
+	// ReasonScore - won by counting, Result.Margin holds the score difference
+	ReasonScore
+	ReasonResign
+	ReasonTime
+	ReasonForfeit
+	// ReasonDraw - the game is a draw (jigo), there is no winner
+	ReasonDraw
+	// ReasonVoid - the game was not finished or has no result, there is no winner
+	ReasonVoid
+)
+
+func (reason ResultReason) String() string {
+	switch reason {
+	case ReasonUnknown:
+		return "unknown"
+	case ReasonScore:
+		return "score"
+	case ReasonResign:
+		return "resignation"
+	case ReasonTime:
+		return "time"
+	case ReasonForfeit:
+		return "forfeit"
+	case ReasonDraw:
+		return "draw"
+	case ReasonVoid:
+		return "void"
+	}
+	return fmt.Sprintf("ResultReason(%d)", int(reason))
+}
+
+// Result is the outcome of a game as described by the RE property
+type Result struct {
+	// Winner is Black or White, 0 if there is no winner or it's unknown
+	Winner Color
+	// Margin is the score difference for ReasonScore
+	Margin float64
+	Reason ResultReason
+}
+
+// ParseResult parses an RE value as defined in FF[4]: "B+" or "W+" followed by the score, "R"/"Resign",
+// "T"/"Time", "F"/"Forfeit" or nothing, "0" or "Draw" for a draw, "Void" for no result and "?" for an unknown result.
+func ParseResult(value PropValue) (Result, error) {
+	text := strings.TrimSpace(string(value))
+	invalid := &ValueError{Ident: "RE", Value: value, Type: SimpleTextValue, Msg: "not a valid result"}
+
+	switch text {
+	case "0", "Draw":
+		return Result{Reason: ReasonDraw}, nil
+	case "Void":
+		return Result{Reason: ReasonVoid}, nil
+	case "?":
+		return Result{Reason: ReasonUnknown}, nil
+	}
+
+	winner, how, found := strings.Cut(text, "+")
+	if !found {
+		return Result{}, invalid
+	}
+
+	result := Result{}
+	switch winner {
+	case "B":
+		result.Winner = Black
+	case "W":
+		result.Winner = White
+	default:
+		return Result{}, invalid
+	}
+
+	switch how {
+	case "":
+		result.Reason = ReasonUnknown
+	case "R", "Resign":
+		result.Reason = ReasonResign
+	case "T", "Time":
+		result.Reason = ReasonTime
+	case "F", "Forfeit":
+		result.Reason = ReasonForfeit
+	default:
+		if !isReal(how) {
+			return Result{}, invalid
+		}
+		margin, err := strconv.ParseFloat(how, 64)
+		if err != nil {
+			return Result{}, invalid
+		}
+		result.Reason, result.Margin = ReasonScore, margin
+	}
+	return result, nil
+}
+
+// String formats the result as RE value in the short FF[4] form, e.g. "B+3.5", "W+R", "0" or "?"
+func (result Result) String() string {
+	switch result.Reason {
+	case ReasonDraw:
+		return "0"
+	case ReasonVoid:
+		return "Void"
+	}
+	if result.Winner != Black && result.Winner != White {
+		return "?"
+	}
+
+	output := result.Winner.String() + "+"
+	switch result.Reason {
+	case ReasonScore:
+		output += strconv.FormatFloat(result.Margin, 'f', -1, 64)
+	case ReasonResign:
+		output += "R"
+	case ReasonTime:
+		output += "T"
+	case ReasonForfeit:
+		output += "F"
+	}
+	return output
+}
+
+// PropValue returns the result as RE value
+func (result Result) PropValue() PropValue {
+	return PropValue(result.String())
+}
+
+// lenientWinner matches the winner at the start of a non-standard result, e.g. "Black+", "W wins", "white won"
+var lenientWinner = regexp.MustCompile(`^(b|black|w|white)(\s*\+|\s+(?:wins?|won)\b)\s*(.*)$`)
+
+// lenientMargin matches a score with an optional unit, e.g. "2.5", "3 points", "0,5 moku"
+var lenientMargin = regexp.MustCompile(`^(\d+(?:[.,]\d+)?)\s*(?:points?|pts?|moku)?$`)
+
+// ParseLenientResult parses an RE value like ParseResult, but also accepts common non-standard variants: any case,
+// long words ("B+Resignation", "Black+Time"), sentences ("White wins by 2.5 points", "Black won on time") and
+// other words for a draw ("Jigo") or no result ("No result").
+func ParseLenientResult(value PropValue) (Result, error) {
+	if result, err := ParseResult(value); err == nil {
+		return result, nil
+	}
+	invalid := &ValueError{Ident: "RE", Value: value, Type: SimpleTextValue, Msg: "not a valid result"}
+
+	text := strings.ToLower(strings.Join(strings.Fields(string(value)), " "))
+	text = strings.TrimSuffix(text, ".")
+
+	switch text {
+	case "0", "draw", "jigo", "tie":
+		return Result{Reason: ReasonDraw}, nil
+	case "void", "no result", "none":
+		return Result{Reason: ReasonVoid}, nil
+	case "?", "unknown":
+		return Result{Reason: ReasonUnknown}, nil
+	}
+
+	match := lenientWinner.FindStringSubmatch(text)
+	if match == nil {
+		return Result{}, invalid
+	}
+
+	result := Result{Winner: Black}
+	if strings.HasPrefix(match[1], "w") {
+		result.Winner = White
+	}
+
+	how := match[3]
+	for _, word := range []string{"by ", "on ", "with "} {
+		how = strings.TrimPrefix(how, word)
+	}
+
+	switch how {
+	case "":
+		result.Reason = ReasonUnknown
+	case "r", "res", "resign", "resigns", "resigned", "resignation":
+		result.Reason = ReasonResign
+	case "t", "time", "timeout", "time out":
+		result.Reason = ReasonTime
+	case "f", "forfeit", "default":
+		result.Reason = ReasonForfeit
+	default:
+		margin := lenientMargin.FindStringSubmatch(how)
+		if margin == nil {
+			return Result{}, invalid
+		}
+		number, err := strconv.ParseFloat(strings.Replace(margin[1], ",", ".", 1), 64)
+		if err != nil {
+			return Result{}, invalid
+		}
+		result.Reason, result.Margin = ReasonScore, number
+	}
+	return result, nil
+}
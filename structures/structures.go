@@ -1,6 +1,9 @@
 package structures
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	// SequenceStart starts new variation sequence
@@ -17,6 +20,8 @@ const (
 
 type Collection struct {
 	GameTrees []*GameTree
+	// Charset is the character set the collection was converted from (see parser.ParseFile). Empty means UTF-8.
+	Charset string
 }
 
 func (collection Collection) String() string {
@@ -59,6 +64,8 @@ func (sequence Sequence) String() string {
 
 // Node is the container for properties with their keys and values
 type Node struct {
+	// Id numbers the nodes of a collection in pre-order, starting with 1 (see Collection.AssignIDs). The parser
+	// assigns it. 0 means not assigned.
 	Id         int
 	Properties []Property
 }
@@ -72,30 +79,118 @@ func (node Node) String() string {
 	return string(NodeSeparator) + output
 }
 
-type propertyType int
+// Property returns the property with the identifier. The pointer refers to the property in the node, so changes made
+// through it are made to the node. It returns false if the node has no such property.
+func (node Node) Property(ident PropIdent) (*Property, bool) {
+	for i := range node.Properties {
+		if node.Properties[i].Ident == ident {
+			return &node.Properties[i], true
+		}
+	}
+	return nil, false
+}
+
+// Move returns the move of the node (its B or W property) as SGF, e.g. "B[pd]", or an empty string if the node has
+// no move. Variations are paired by their first moves when comparing or merging game trees.
+func (node Node) Move() string {
+	for _, prop := range node.Properties {
+		if prop.Ident == "B" || prop.Ident == "W" {
+			return prop.String()
+		}
+	}
+	return ""
+}
+
+// PropertyType is the category of a property as defined in FF[4]
+type PropertyType int
 
 const (
-	move propertyType = iota
-	setup
-	root
-	gameInfo
-	none
+	NoneProperty PropertyType = iota
+	MoveProperty
+	SetupProperty
+	RootProperty
+	GameInfoProperty
 )
 
+func (propType PropertyType) String() string {
+	switch propType {
+	case NoneProperty:
+		return "none"
+	case MoveProperty:
+		return "move"
+	case SetupProperty:
+		return "setup"
+	case RootProperty:
+		return "root"
+	case GameInfoProperty:
+		return "game-info"
+	}
+	return fmt.Sprintf("PropertyType(%d)", int(propType))
+}
+
 // Property is the container for Property and value in SGF files. This means that property is B[xx][x] and not just B
 type Property struct {
-	propType propertyType
-	Ident    PropIdent
-	Values   []PropValue
+	Ident  PropIdent
+	Values []PropValue
+	// Raw holds the values exactly as they were found between the brackets (escape characters and line breaks are
+	// kept). It is set by the parser and may be empty for properties created by hand.
+	Raw []PropValue
+}
+
+// Type returns the category of the property from the table of known FF[4] properties. Unknown properties are
+// NoneProperty.
+func (prop Property) Type() PropertyType {
+	info, _ := LookupProperty(prop.Ident)
+	return info.Category
 }
 
 func (prop Property) String() string {
 	output := string(prop.Ident)
-	for _, value := range prop.Values {
+	for _, value := range prop.EscapedValues() {
 		output += fmt.Sprintf("[%s]", value)
 	}
 	return output
 }
 
+// EscapedValues returns the values of the property as they must be written between the brackets in an SGF file.
+// Values which were not changed since parsing are returned exactly as they were found in the parsed file.
+func (prop Property) EscapedValues() []string {
+	info, _ := LookupProperty(prop.Ident)
+
+	escaped := make([]string, 0, len(prop.Values))
+	for i, value := range prop.Values {
+		if raw, _, ok := prop.rawAt(i); ok {
+			escaped = append(escaped, string(raw))
+			continue
+		}
+		if info.HasCompose() {
+			// escape the parts separately, so that colons within them are not mistaken for the separator
+			if left, right, ok := prop.composeAt(i); ok {
+				escaped = append(escaped, PropValue(left).Escape(true)+":"+PropValue(right).Escape(true))
+				continue
+			}
+		}
+		escaped = append(escaped, value.Escape(info.HasCompose()))
+	}
+	return escaped
+}
+
 type PropIdent string
 type PropValue string
+
+// Escape returns the value with "]" and "\" escaped. If composed is true, ":" is escaped as well - this is used for
+// the parts of composed values, which are joined by an unescaped ":" afterwards (see Property.EscapedValues).
+func (value PropValue) Escape(composed bool) string {
+	var output strings.Builder
+
+	for _, r := range value {
+		switch {
+		case r == PropertyValueEnd || r == '\\':
+			output.WriteRune('\\')
+		case r == ':' && composed:
+			output.WriteRune('\\')
+		}
+		output.WriteRune(r)
+	}
+	return output.String()
+}
@@ -0,0 +1,976 @@
+package structures_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/makpoc/sgfparser/internal/sgftest"
+	"github.com/makpoc/sgfparser/structures"
+)
+
+func property(ident string, values ...string) structures.Property {
+	prop := structures.Property{Ident: structures.PropIdent(ident)}
+	for _, value := range values {
+		prop.Values = append(prop.Values, structures.PropValue(value))
+	}
+	return prop
+}
+
+func TestTypedValues(t *testing.T) {
+	if n, err := property("HA", "4").AsNumber(); err != nil || n != 4 {
+		t.Errorf("HA[4]: expected 4, found %d (%v)", n, err)
+	}
+	if n, err := property("MN", "-12").AsNumber(); err != nil || n != -12 {
+		t.Errorf("MN[-12]: expected -12, found %d (%v)", n, err)
+	}
+	if r, err := property("KM", "6.5").AsReal(); err != nil || r != 6.5 {
+		t.Errorf("KM[6.5]: expected 6.5, found %f (%v)", r, err)
+	}
+	if r, err := property("KM", "0").AsReal(); err != nil || r != 0 {
+		t.Errorf("KM[0]: expected 0, found %f (%v)", r, err)
+	}
+	if d, err := property("GB", "2").AsDouble(); err != nil || d != 2 {
+		t.Errorf("GB[2]: expected 2, found %d (%v)", d, err)
+	}
+	if c, err := property("PL", "W").AsColor(); err != nil || c != structures.White {
+		t.Errorf("PL[W]: expected W, found %s (%v)", c, err)
+	}
+	if p, err := property("B", "pd").AsPoint(); err != nil || p != (structures.Point{X: 15, Y: 3}) {
+		t.Errorf("B[pd]: expected {15 3}, found %v (%v)", p, err)
+	}
+	if p, err := property("B", "aZ").AsPoint(); err != nil || p != (structures.Point{X: 0, Y: 51}) {
+		t.Errorf("B[aZ]: expected {0 51}, found %v (%v)", p, err)
+	}
+	if l, r, err := property("LB", "dd:A").AsCompose(); err != nil || l != "dd" || r != "A" {
+		t.Errorf("LB[dd:A]: expected dd and A, found %s and %s (%v)", l, r, err)
+	}
+	if text, err := property("C", "some: text").AsText(); err != nil || text != "some: text" {
+		t.Errorf("C[some: text]: expected the text, found %s (%v)", text, err)
+	}
+	// unknown properties are converted as requested
+	if n, err := property("XX", "7").AsNumber(); err != nil || n != 7 {
+		t.Errorf("XX[7]: expected 7, found %d (%v)", n, err)
+	}
+}
+
+func TestTypedValuesNeg(t *testing.T) {
+	var conversions = []func() error{
+		func() error { _, err := property("HA", "four").AsNumber(); return err },
+		func() error { _, err := property("HA", "+-4").AsNumber(); return err },
+		func() error { _, err := property("C", "4").AsNumber(); return err },
+		func() error { _, err := property("KM", "6.").AsReal(); return err },
+		func() error { _, err := property("GB", "3").AsDouble(); return err },
+		func() error { _, err := property("PL", "").AsColor(); return err },
+		func() error { _, err := property("B", "").AsPoint(); return err },
+		func() error { _, err := property("AB", "aa", "bb").AsPoint(); return err },
+		func() error { _, err := property("C", "dd:A").AsPoint(); return err },
+		func() error { _, _, err := property("LB", "ddA").AsCompose(); return err },
+		func() error { _, _, err := property("B", "aa:bb").AsCompose(); return err },
+	}
+
+	for i, conversion := range conversions {
+		err := conversion()
+		var valueErr *structures.ValueError
+		if !errors.As(err, &valueErr) {
+			t.Errorf("Test %d: expected ValueError, found %v", i, err)
+		}
+	}
+}
+
+func TestValueError(t *testing.T) {
+	type errorStruct struct {
+		err      error
+		expected string
+	}
+
+	var errorMatrix = []errorStruct{
+		{property("HA", "four").Check(), "HA[four] is not a valid Number: value does not match the property type"},
+		{property("AB", "aa", "zz", "b").Check(), "AB[b] (value 3) is not a valid Stone: value does not match the property type"},
+		{property("KM", "6.5", "7").Check(), "KM[6.5][7] is not a valid Real: expected a single value, found 2"},
+		{func() error { _, err := property("AB", "aa", "bb").AsPoint(); return err }(), "AB[aa][bb] is not a valid Point: expected a single value, found 2"},
+		{func() error { _, err := property("TR", "aa", "cc:bb").AsPoints(); return err }(), `TR[cc:bb] (value 2) is not a valid Point: invalid rectangle "cc:bb"`},
+	}
+
+	for i, current := range errorMatrix {
+		if current.err == nil || current.err.Error() != current.expected {
+			t.Errorf("Test %d: expected %q, found %v", i, current.expected, current.err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	type checkStruct struct {
+		prop    structures.Property
+		isError bool
+	}
+
+	var checkMatrix = []checkStruct{
+		{property("B", "pd"), false},
+		{property("B", ""), false},
+		{property("AB", "dd", "do:gq"), false},
+		{property("SZ", "19"), false},
+		{property("SZ", "19:13"), false},
+		{property("FG", ""), false},
+		{property("FG", "257:Figure"), false},
+		{property("VW", ""), false},
+		{property("GN", "Gametree 1: properties"), false},
+		{property("XY", "anything", "goes"), false},
+		{property("FF", "AA"), true},
+		{property("B", "pd", "pe"), true},
+		{property("AB", ""), true},
+		{property("LB", "dd"), true},
+		{property("PL", "X"), true},
+		{property("C"), true},
+	}
+
+	for i, current := range checkMatrix {
+		err := current.prop.Check()
+		if (err != nil) != current.isError {
+			t.Errorf("Test %d: %s returned %v", i, current.prop, err)
+		}
+	}
+}
+
+func TestPropertyType(t *testing.T) {
+	type typeStruct struct {
+		prop     structures.Property
+		propType structures.PropertyType
+	}
+
+	var typeMatrix = []typeStruct{
+		{property("B", "pd"), structures.MoveProperty},
+		{property("TE", "1"), structures.MoveProperty},
+		{property("BL", "120.5"), structures.MoveProperty},
+		{property("OW", "3"), structures.MoveProperty},
+		{property("AB", "pd"), structures.SetupProperty},
+		{property("PL", "B"), structures.SetupProperty},
+		{property("SZ", "19"), structures.RootProperty},
+		{property("KM", "6.5"), structures.GameInfoProperty},
+		{property("PB", "Honinbo"), structures.GameInfoProperty},
+		{property("C", "comment"), structures.NoneProperty},
+		{property("XX", "unknown"), structures.NoneProperty},
+	}
+
+	for i, current := range typeMatrix {
+		if propType := current.prop.Type(); propType != current.propType {
+			t.Errorf("Test %d: expected %s to be %s, found %s", i, current.prop, current.propType, propType)
+		}
+	}
+
+	// the type follows changes of the identifier
+	prop := property("B", "pd")
+	prop.Ident = "AB"
+	if propType := prop.Type(); propType != structures.SetupProperty {
+		t.Errorf("Expected %s to be %s, found %s", prop, structures.SetupProperty, propType)
+	}
+}
+
+func TestNodeCheck(t *testing.T) {
+	type nodeStruct struct {
+		node   structures.Node
+		isRoot bool
+		errs   []error
+	}
+
+	var nodeMatrix = []nodeStruct{
+		{structures.Node{Properties: []structures.Property{property("FF", "4"), property("AB", "aa"), property("PL", "W")}}, true, nil},
+		{structures.Node{Properties: []structures.Property{property("B", "aa"), property("C", "x")}}, false, nil},
+		{structures.Node{Properties: []structures.Property{property("B", "aa"), property("AW", "bb")}}, false, []error{structures.MixedMoveSetupError}},
+		{structures.Node{Properties: []structures.Property{property("B", "aa"), property("SZ", "19")}}, false, []error{structures.MisplacedRootPropertyError}},
+		{structures.Node{Properties: []structures.Property{property("AB", "aa"), property("BL", "30")}}, false, []error{structures.MixedMoveSetupError}},
+		{structures.Node{Properties: []structures.Property{property("GM", "1"), property("W", "aa"), property("AE", "bb")}}, false,
+			[]error{structures.MisplacedRootPropertyError, structures.MixedMoveSetupError}},
+	}
+
+	for i, current := range nodeMatrix {
+		err := current.node.Check(current.isRoot)
+		if len(current.errs) == 0 && err != nil {
+			t.Errorf("Test %d: expected no error, found %v", i, err)
+		}
+		for _, expected := range current.errs {
+			if !errors.Is(err, expected) {
+				t.Errorf("Test %d: expected %v, found %v", i, expected, err)
+			}
+		}
+	}
+
+	tree := &structures.GameTree{Sequence: structures.Sequence{Nodes: []structures.Node{
+		{Properties: []structures.Property{property("SZ", "19")}},
+	}}}
+	child := &structures.GameTree{Parent: tree, Sequence: structures.Sequence{Nodes: []structures.Node{
+		{Properties: []structures.Property{property("B", "aa"), property("SZ", "19")}},
+	}}}
+	tree.Children = append(tree.Children, child)
+
+	if err := tree.Check(); !errors.Is(err, structures.MisplacedRootPropertyError) {
+		t.Errorf("Expected root property error in child tree, found %v", err)
+	}
+}
+
+func TestNodeProperty(t *testing.T) {
+	node := structures.Node{Properties: []structures.Property{property("C", "a"), property("W", "bb")}}
+
+	prop, found := node.Property("C")
+	if !found || prop.String() != "C[a]" {
+		t.Errorf("Expected C[a], found %v", prop)
+	}
+	// changes are made to the node
+	*prop = property("C", "b")
+	if node.String() != ";C[b]W[bb]" {
+		t.Errorf("Expected the changed comment, found %s", node)
+	}
+	if _, found := node.Property("B"); found {
+		t.Errorf("Expected no B property")
+	}
+
+	if move := node.Move(); move != "W[bb]" {
+		t.Errorf("Expected the move W[bb], found %q", move)
+	}
+	if move := (structures.Node{Properties: []structures.Property{property("AB", "aa")}}).Move(); move != "" {
+		t.Errorf("Expected no move, found %q", move)
+	}
+}
+
+func comment(node *structures.Node) string {
+	for _, prop := range node.Properties {
+		if prop.Ident == "C" {
+			return string(prop.Values[0])
+		}
+	}
+	return ""
+}
+
+func TestCursor(t *testing.T) {
+	cursor := structures.NewCursor(sgftest.ParseGameTree(t, "(;C[root](;C[a];C[b](;C[c])(;C[d];C[e]))(;C[f]))"))
+
+	expect := func(step string, expected string, depth int) {
+		t.Helper()
+		if found := comment(cursor.Node()); found != expected {
+			t.Errorf("%s: expected node %s, found %s", step, expected, found)
+		}
+		if found := cursor.Depth(); found != depth {
+			t.Errorf("%s: expected depth %d, found %d", step, depth, found)
+		}
+	}
+
+	expect("start", "root", 0)
+	if cursor.Prev() || cursor.Up() {
+		t.Errorf("Moved above the root")
+	}
+	if !cursor.Next(1) {
+		t.Errorf("Failed to move to variation 1")
+	}
+	expect("Next(1)", "f", 1)
+	if cursor.Next(0) {
+		t.Errorf("Moved past the end of the variation")
+	}
+
+	cursor.ToRoot()
+	cursor.ToEnd()
+	expect("ToEnd", "c", 3)
+
+	cursor.Prev()
+	cursor.Next(1)
+	cursor.Next(0)
+	expect("Next(1), Next(0)", "e", 4)
+	if path := cursor.Path(); fmt.Sprint(path) != "[0 0 1 0]" {
+		t.Errorf("Expected path [0 0 1 0], found %v", path)
+	}
+
+	cursor.Prev()
+	siblings, index := cursor.Siblings()
+	if len(siblings) != 2 || index != 1 || comment(siblings[0]) != "c" {
+		t.Errorf("Expected siblings c and d with d current, found %d siblings, current %d", len(siblings), index)
+	}
+
+	if !cursor.Up() {
+		t.Errorf("Failed to move up")
+	}
+	expect("Up", "b", 2)
+	cursor.Up()
+	expect("Up", "root", 0)
+
+	cursor.ToRoot()
+	expect("ToRoot", "root", 0)
+}
+
+func TestVariations(t *testing.T) {
+	tree := sgftest.ParseGameTree(t, "(;C[root](;C[a];C[b](;C[c])(;C[d];C[e]))(;C[f]))")
+
+	line := func(nodes []structures.Node) string {
+		return structures.Sequence{Nodes: nodes}.String()
+	}
+
+	if mainLine := line(tree.MainLine()); mainLine != ";C[root];C[a];C[b];C[c]" {
+		t.Errorf("Unexpected main line %s", mainLine)
+	}
+
+	var lines []string
+	for _, nodes := range tree.Lines() {
+		lines = append(lines, line(nodes))
+	}
+	expected := []string{";C[root];C[a];C[b];C[c]", ";C[root];C[a];C[b];C[d];C[e]", ";C[root];C[f]"}
+	if fmt.Sprint(lines) != fmt.Sprint(expected) {
+		t.Errorf("Expected lines %v, found %v", expected, lines)
+	}
+
+	collection := structures.Collection{GameTrees: []*structures.GameTree{tree}}
+	if stripped := collection.StripVariations().String(); stripped != "(;C[root];C[a];C[b];C[c])" {
+		t.Errorf("Unexpected stripped collection %s", stripped)
+	}
+	if split := collection.SplitVariations().String(); split != "(;C[root];C[a];C[b];C[c])(;C[root];C[a];C[b];C[d];C[e])(;C[root];C[f])" {
+		t.Errorf("Unexpected split collection %s", split)
+	}
+
+	collection.Charset = "Shift_JIS"
+	if stripped, split := collection.StripVariations(), collection.SplitVariations(); stripped.Charset != "Shift_JIS" || split.Charset != "Shift_JIS" {
+		t.Errorf("Expected the charset to be kept, found %q and %q", stripped.Charset, split.Charset)
+	}
+
+	// the results must not share values with the original tree
+	collection.StripVariations().GameTrees[0].Sequence.Nodes[0].Properties[0].Values[0] = "changed"
+	if comment(&tree.Sequence.Nodes[0]) != "root" {
+		t.Errorf("Changing the stripped collection changed the original")
+	}
+}
+
+func TestPoints(t *testing.T) {
+	points, err := property("AB", "do:eq", "aa", "Za:ZZ").AsPoints()
+	if err != nil {
+		t.Fatalf("Failed to expand points: %s", err.Error())
+	}
+	if len(points) != 6+1+52 {
+		t.Errorf("Expected 59 points, found %d: %v", len(points), points)
+	}
+	if points[0] != (structures.Point{X: 3, Y: 14}) || points[6] != (structures.Point{X: 0, Y: 0}) || points[len(points)-1] != (structures.Point{X: 51, Y: 51}) {
+		t.Errorf("Unexpected points %v", points)
+	}
+
+	if compressed := fmt.Sprint(structures.CompressPoints(points)); compressed != "[aa Za:ZZ do:eq]" {
+		t.Errorf("Unexpected compressed points %s", compressed)
+	}
+
+	// an L shape needs two rectangles
+	lShape, _ := structures.ExpandPoints([]structures.PropValue{"aa:ac", "bc:cc"})
+	if compressed := fmt.Sprint(structures.CompressPoints(lShape)); compressed != "[aa:ac bc:cc]" {
+		t.Errorf("Unexpected compressed points %s", compressed)
+	}
+
+	if points, err := property("VW", "").AsPoints(); err != nil || len(points) != 0 {
+		t.Errorf("Expected no points for VW[], found %v (%v)", points, err)
+	}
+	for _, prop := range []structures.Property{property("AB", "dd:cc"), property("AB", "d"), property("C", "aa")} {
+		if _, err := prop.AsPoints(); err == nil {
+			t.Errorf("Expected an error for %s", prop)
+		}
+	}
+}
+
+func TestCompose(t *testing.T) {
+	type composeStruct struct {
+		raw   string
+		left  string
+		right string
+		ok    bool
+	}
+
+	var composeMatrix = []composeStruct{
+		{"(;LB[dd:A])", "dd", "A", true},
+		{"(;AP[Primiview:3.1])", "Primiview", "3.1", true},
+		{"(;AP[Prim\\:iview:3\\:1])", "Prim:iview", "3:1", true},
+		{"(;FG[257:Figure\\]1])", "257", "Figure]1", true},
+		{"(;LB[dd\\:A])", "", "", false},
+		{"(;LB[dd:A][ee:B])", "", "", false},
+	}
+
+	for i, current := range composeMatrix {
+		prop := sgftest.ParseGameTree(t, current.raw).Sequence.Nodes[0].Properties[0]
+		left, right, ok := prop.Compose()
+		if left != current.left || right != current.right || ok != current.ok {
+			t.Errorf("Test %d: expected (%q, %q, %t), found (%q, %q, %t)", i, current.left, current.right, current.ok, left, right, ok)
+		}
+	}
+
+	// properties created by hand are split at the first colon
+	if left, right, ok := property("LB", "dd:A:B").Compose(); left != "dd" || right != "A:B" || !ok {
+		t.Errorf("Expected dd and A:B, found %q and %q", left, right)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type jsonStruct struct {
+		raw     string
+		encoded string
+	}
+
+	var jsonMatrix = []jsonStruct{
+		{"(;FF[4]AB[aa][bb];B[cc])", `{"gameTrees":[{"nodes":[{"properties":[{"ident":"FF","values":["4"]},{"ident":"AB","values":["aa","bb"]}]},{"properties":[{"ident":"B","values":["cc"]}]}]}]}`},
+		{"(;C[a](;B[aa])(;B[bb];W[cc]))", `{"gameTrees":[{"nodes":[{"properties":[{"ident":"C","values":["a"]}]}],"variations":[{"nodes":[{"properties":[{"ident":"B","values":["aa"]}]}]},{"nodes":[{"properties":[{"ident":"B","values":["bb"]}]},{"properties":[{"ident":"W","values":["cc"]}]}]}]}]}`},
+		{"(;AP[Prim\\:iview:3.1]C[a\\]b])", `{"gameTrees":[{"nodes":[{"properties":[{"ident":"AP","values":["Prim:iview:3.1"],"raw":["Prim\\:iview:3.1"]},{"ident":"C","values":["a]b"]}]}]}]}`},
+		{"(;C[a];C[b])", `{"gameTrees":[{"nodes":[{"id":1,"properties":[{"ident":"C","values":["a"]}]},{"id":2,"properties":[{"ident":"C","values":["b"]}]}]}]}`},
+	}
+
+	for i, current := range jsonMatrix {
+		collection := &structures.Collection{GameTrees: []*structures.GameTree{sgftest.ParseGameTree(t, current.raw)}}
+		if strings.Contains(current.encoded, `"id"`) {
+			collection.AssignIDs()
+		}
+
+		encoded, err := json.Marshal(collection)
+		if err != nil {
+			t.Fatalf("Test %d: Marshal returned error! %s", i, err.Error())
+		}
+		if string(encoded) != current.encoded {
+			t.Errorf("Test %d: expected %s, found %s", i, current.encoded, encoded)
+		}
+
+		decoded := new(structures.Collection)
+		if err := json.Unmarshal(encoded, decoded); err != nil {
+			t.Fatalf("Test %d: Unmarshal returned error! %s", i, err.Error())
+		}
+		if decoded.String() != current.raw {
+			t.Errorf("Test %d: expected %s after decoding, found %s", i, current.raw, decoded.String())
+		}
+		if decoded.GameTrees[0].Sequence.Nodes[0].Id != collection.GameTrees[0].Sequence.Nodes[0].Id {
+			t.Errorf("Test %d: expected the Id to be decoded", i)
+		}
+		if err := checkParents(decoded.GameTrees[0], nil); err != nil {
+			t.Errorf("Test %d: %s", i, err.Error())
+		}
+		if decoded.GameTrees[0].Sequence.Nodes[0].Properties[0].Type() != collection.GameTrees[0].Sequence.Nodes[0].Properties[0].Type() {
+			t.Errorf("Test %d: expected the same property type after decoding", i)
+		}
+	}
+}
+
+func TestJSONNeg(t *testing.T) {
+	type jsonStruct struct {
+		encoded string
+		err     error
+	}
+
+	var jsonMatrix = []jsonStruct{
+		{`{"gameTrees":[{"nodes":[]}]}`, structures.EmptyGameTreeError},
+		{`{"gameTrees":[{"nodes":[{"properties":[{"ident":"C","values":["a"]}]}],"variations":[{}]}]}`, structures.EmptyGameTreeError},
+		{`{"gameTrees":[{"nodes":[{"properties":[{"values":["a"]}]}]}]}`, structures.EmptyPropIdentError},
+		{`{"gameTrees":[null]}`, structures.EmptyGameTreeError},
+		{`{"gameTrees":[{"nodes":[{"properties":[]}],"variations":[null]}]}`, structures.EmptyGameTreeError},
+	}
+
+	for i, current := range jsonMatrix {
+		if err := json.Unmarshal([]byte(current.encoded), new(structures.Collection)); !errors.Is(err, current.err) {
+			t.Errorf("Test %d: expected error %v, found %v", i, current.err, err)
+		}
+	}
+}
+
+// checkParents verifies that the Parent of every game tree points to the tree holding it in its Children
+func checkParents(tree, parent *structures.GameTree) error {
+	if tree.Parent != parent {
+		return fmt.Errorf("wrong parent of %s", tree)
+	}
+	for _, child := range tree.Children {
+		if err := checkParents(child, tree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestParseResult(t *testing.T) {
+	type resultStruct struct {
+		value  string
+		result structures.Result
+		err    bool
+	}
+
+	var resultMatrix = []resultStruct{
+		{"B+3.5", structures.Result{Winner: structures.Black, Margin: 3.5, Reason: structures.ReasonScore}, false},
+		{"W+12", structures.Result{Winner: structures.White, Margin: 12, Reason: structures.ReasonScore}, false},
+		{"W+R", structures.Result{Winner: structures.White, Reason: structures.ReasonResign}, false},
+		{"B+Resign", structures.Result{Winner: structures.Black, Reason: structures.ReasonResign}, false},
+		{"B+T", structures.Result{Winner: structures.Black, Reason: structures.ReasonTime}, false},
+		{"W+Forfeit", structures.Result{Winner: structures.White, Reason: structures.ReasonForfeit}, false},
+		{"B+", structures.Result{Winner: structures.Black, Reason: structures.ReasonUnknown}, false},
+		{"0", structures.Result{Reason: structures.ReasonDraw}, false},
+		{"Draw", structures.Result{Reason: structures.ReasonDraw}, false},
+		{"Void", structures.Result{Reason: structures.ReasonVoid}, false},
+		{"?", structures.Result{Reason: structures.ReasonUnknown}, false},
+		{"X+3", structures.Result{}, true},
+		{"B+3.5.1", structures.Result{}, true},
+		{"Black wins", structures.Result{}, true},
+	}
+
+	for i, current := range resultMatrix {
+		result, err := structures.ParseResult(structures.PropValue(current.value))
+		if (err != nil) != current.err {
+			t.Errorf("Test %d: unexpected error for %q: %v", i, current.value, err)
+			continue
+		}
+		if result != current.result {
+			t.Errorf("Test %d: expected %+v, found %+v", i, current.result, result)
+		}
+	}
+}
+
+func TestFormatResult(t *testing.T) {
+	for i, value := range []string{"B+3.5", "W+12", "W+R", "B+T", "W+F", "B+", "0", "Void", "?"} {
+		result, err := structures.ParseResult(structures.PropValue(value))
+		if err != nil {
+			t.Fatalf("Test %d: ParseResult returned error! %s", i, err.Error())
+		}
+		if result.PropValue() != structures.PropValue(value) {
+			t.Errorf("Test %d: expected %s, found %s", i, value, result.PropValue())
+		}
+	}
+
+	type formatStruct struct {
+		result structures.Result
+		value  string
+	}
+
+	var formatMatrix = []formatStruct{
+		{structures.Result{Winner: structures.Black, Margin: 0.5, Reason: structures.ReasonScore}, "B+0.5"},
+		{structures.Result{Winner: structures.White, Reason: structures.ReasonResign}, "W+R"},
+		{structures.Result{Reason: structures.ReasonDraw}, "0"},
+		{structures.Result{Reason: structures.ReasonResign}, "?"},
+		{structures.Result{}, "?"},
+	}
+
+	for i, current := range formatMatrix {
+		if current.result.String() != current.value {
+			t.Errorf("Test %d: expected %s, found %s", i, current.value, current.result.String())
+		}
+	}
+}
+
+func TestParseLenientResult(t *testing.T) {
+	type resultStruct struct {
+		value  string
+		result string
+	}
+
+	var resultMatrix = []resultStruct{
+		{"B+3.5", "B+3.5"},
+		{"b+r", "B+R"},
+		{"B+Resignation", "B+R"},
+		{"W+Res.", "W+R"},
+		{"Black+Resign", "B+R"},
+		{"White wins by 2.5", "W+2.5"},
+		{"White wins by 2,5 points", "W+2.5"},
+		{"B + 7 moku", "B+7"},
+		{"Black won on time", "B+T"},
+		{"W+Timeout", "W+T"},
+		{"white wins by forfeit", "W+F"},
+		{"Black wins", "B+"},
+		{"Jigo", "0"},
+		{"draw", "0"},
+		{"No result", "Void"},
+		{"unknown", "?"},
+	}
+
+	for i, current := range resultMatrix {
+		result, err := structures.ParseLenientResult(structures.PropValue(current.value))
+		if err != nil {
+			t.Errorf("Test %d: unexpected error for %q: %v", i, current.value, err)
+			continue
+		}
+		if result.String() != current.result {
+			t.Errorf("Test %d: expected %s for %q, found %s", i, current.result, current.value, result)
+		}
+	}
+
+	for i, value := range []string{"Black", "B+lots", "Whitewins", "X+3", "wins by 3", ""} {
+		if result, err := structures.ParseLenientResult(structures.PropValue(value)); err == nil {
+			t.Errorf("Test %d: expected an error for %q, found %s", i, value, result)
+		}
+	}
+}
+
+func TestParseDates(t *testing.T) {
+	type dateStruct struct {
+		value     string
+		dates     string
+		formatted string
+	}
+
+	var dateMatrix = []dateStruct{
+		{"1996-05-06", "1996-05-06", "1996-05-06"},
+		{"1996", "1996", "1996"},
+		{"1996-05", "1996-05", "1996-05"},
+		{"1996-05-06,07,08", "1996-05-06 1996-05-07 1996-05-08", "1996-05-06,07,08"},
+		{"1996-05,06", "1996-05 1996-06", "1996-05,06"},
+		{"1996-12-27,28,1997-01-03", "1996-12-27 1996-12-28 1997-01-03", "1996-12-27,28,1997-01-03"},
+		{"1996-05-06,07-01,02", "1996-05-06 1996-07-01 1996-07-02", "1996-05-06,07-01,02"},
+		{"1996-02-29", "1996-02-29", "1996-02-29"},
+		// sorted, without duplicates and shortened
+		{"1997-01-03, 1996-12-28,1996-12-27,1997-01-03", "1996-12-27 1996-12-28 1997-01-03", "1996-12-27,28,1997-01-03"},
+		{"1996-05-06,1996-05-07,1996-06-01", "1996-05-06 1996-05-07 1996-06-01", "1996-05-06,07,06-01"},
+		{"1996-05-06,1996,1996-05", "1996 1996-05 1996-05-06", "1996,1996-05,1996-05-06"},
+		{"1996-06,1996-05-06", "1996-05-06 1996-06", "1996-05-06,1996-06"},
+	}
+
+	for i, current := range dateMatrix {
+		dates, err := structures.ParseDates(structures.PropValue(current.value))
+		if err != nil {
+			t.Errorf("Test %d: unexpected error for %q: %v", i, current.value, err)
+			continue
+		}
+		if output := strings.Trim(fmt.Sprint([]structures.Date(dates)), "[]"); output != current.dates {
+			t.Errorf("Test %d: expected %s, found %s", i, current.dates, output)
+		}
+		if dates.String() != current.formatted {
+			t.Errorf("Test %d: expected %s, found %s", i, current.formatted, dates.String())
+		}
+	}
+}
+
+func TestParseDatesNeg(t *testing.T) {
+	type dateStruct struct {
+		value string
+		index int
+		msg   string
+	}
+
+	var dateMatrix = []dateStruct{
+		{"1997-02-29", 0, "invalid day 29"},
+		{"1996-13", 0, "invalid month 13"},
+		{"1996-05-00", 0, "month and day must not be 00"},
+		{"96-05-06", 0, "expected YYYY-MM-DD or a shortened form"},
+		{"1996-05-06-07", 0, "expected YYYY-MM-DD or a shortened form"},
+		{"06", 0, "a shortened date must follow a date with a month"},
+		{"1996,05", 1, "a shortened date must follow a date with a month"},
+		{"1996-05,06-01", 1, "MM-DD must follow a full date"},
+		{"1996-05-06,07,32", 2, "invalid day 32"},
+		{"May 1996", 0, "expected YYYY-MM-DD or a shortened form"},
+		{"1996-05-06,", 1, "empty date"},
+		{"", 0, "empty date"},
+	}
+
+	for i, current := range dateMatrix {
+		_, err := structures.ParseDates(structures.PropValue(current.value))
+		var dateErr *structures.DateError
+		if !errors.As(err, &dateErr) {
+			t.Errorf("Test %d: expected a DateError for %q, found %v", i, current.value, err)
+			continue
+		}
+		if dateErr.Index != current.index || dateErr.Msg != current.msg {
+			t.Errorf("Test %d: expected %q at %d, found %s", i, current.msg, current.index, err.Error())
+		}
+	}
+}
+
+func TestCompareDates(t *testing.T) {
+	var dates []structures.DateList
+	for _, value := range []string{"1997-01-03", "1996-12-27,28", "1996", "1996-12-27", "1996-12"} {
+		parsed, err := structures.ParseDates(structures.PropValue(value))
+		if err != nil {
+			t.Fatalf("ParseDates returned error! %s", err.Error())
+		}
+		dates = append(dates, parsed)
+	}
+
+	slices.SortFunc(dates, structures.DateList.Compare)
+	expected := "[1996 1996-12 1996-12-27 1996-12-27,28 1997-01-03]"
+	if fmt.Sprint(dates) != expected {
+		t.Errorf("Expected %s, found %v", expected, dates)
+	}
+}
+
+func TestGameInfo(t *testing.T) {
+	gTree := sgftest.ParseGameTree(t, "(;FF[4]GM[1]PB[Honinbo Shusaku]BR[4d]PW[Gennan Inseki]WR[8d]RE[B+2]DT[1846-09-11,12,14]"+
+		"KM[0]HA[0]RU[Japanese]EV[Ear-reddening game]RO[1]PC[Osaka];B[qd])")
+
+	info, err := gTree.Info()
+	if err != nil {
+		t.Fatalf("Info returned error! %s", err.Error())
+	}
+
+	expected := structures.GameInfo{
+		BlackPlayer: "Honinbo Shusaku", WhitePlayer: "Gennan Inseki", BlackRank: "4d", WhiteRank: "8d",
+		Result: structures.Result{Winner: structures.Black, Margin: 2, Reason: structures.ReasonScore},
+		Dates:  structures.DateList{{Year: 1846, Month: 9, Day: 11}, {Year: 1846, Month: 9, Day: 12}, {Year: 1846, Month: 9, Day: 14}},
+		Rules:  "Japanese", Event: "Ear-reddening game", Round: "1", Place: "Osaka",
+	}
+	if fmt.Sprintf("%+v", info) != fmt.Sprintf("%+v", expected) {
+		t.Errorf("Expected %+v, found %+v", expected, info)
+	}
+
+	// game-info in a later node of the main line, invalid values are reported
+	gTree = sgftest.ParseGameTree(t, "(;FF[4]GM[1];C[intro](;PB[a]KM[x]HA[2]RE[?])(;PB[b]))")
+	info, err = gTree.Info()
+	var valueErr *structures.ValueError
+	if !errors.As(err, &valueErr) || valueErr.Ident != "KM" {
+		t.Errorf("Expected a KM value error, found %v", err)
+	}
+	if info.BlackPlayer != "a" || info.Handicap != 2 || info.Komi != 0 || info.Result.Reason != structures.ReasonUnknown {
+		t.Errorf("Unexpected game-info %+v", info)
+	}
+}
+
+func TestEdit(t *testing.T) {
+	type editStruct struct {
+		raw string
+		// variations leads from the top level tree to the edited one
+		variations []int
+		edit       func(tree *structures.GameTree) error
+		expected   string
+	}
+
+	move := structures.Node{Properties: []structures.Property{property("C", "new")}}
+
+	var editMatrix = []editStruct{
+		{"(;C[a];C[b])", nil, func(tree *structures.GameTree) error { return tree.InsertAfter(0, move) }, "(;C[a];C[new];C[b])"},
+		{"(;C[a](;C[b])(;C[c]))", nil, func(tree *structures.GameTree) error { return tree.InsertAfter(0, move) }, "(;C[a];C[new](;C[b])(;C[c]))"},
+		{"(;C[a];C[b];C[c])", nil, func(tree *structures.GameTree) error {
+			_, err := tree.Split(0)
+			return err
+		}, "(;C[a](;C[b];C[c]))"},
+		{"(;C[a];C[b](;C[c])(;C[d]))", nil, func(tree *structures.GameTree) error {
+			_, err := tree.Split(0)
+			return err
+		}, "(;C[a](;C[b](;C[c])(;C[d])))"},
+		{"(;C[a];C[b])", nil, func(tree *structures.GameTree) error {
+			_, err := tree.Split(1)
+			return err
+		}, "(;C[a];C[b])"},
+		{"(;C[a];C[b];C[c])", nil, func(tree *structures.GameTree) error {
+			_, err := tree.AddVariation(1, move)
+			return err
+		}, "(;C[a];C[b](;C[c])(;C[new]))"},
+		{"(;C[a];C[b])", nil, func(tree *structures.GameTree) error {
+			_, err := tree.AddVariation(1, move)
+			return err
+		}, "(;C[a];C[b];C[new])"},
+		{"(;C[a](;C[b])(;C[c]))", []int{1}, func(tree *structures.GameTree) error {
+			_, err := tree.AddVariation(0, move, move)
+			return err
+		}, "(;C[a](;C[b])(;C[c];C[new];C[new]))"},
+		{"(;C[a];C[b];C[c])", nil, func(tree *structures.GameTree) error {
+			return tree.AttachVariation(0, sgftest.ParseGameTree(t, "(;C[x](;C[y])(;C[z]))"))
+		}, "(;C[a](;C[b];C[c])(;C[x](;C[y])(;C[z])))"},
+		{"(;C[a];C[b])", nil, func(tree *structures.GameTree) error {
+			return tree.AttachVariation(1, sgftest.ParseGameTree(t, "(;C[x](;C[y])(;C[z]))"))
+		}, "(;C[a];C[b];C[x](;C[y])(;C[z]))"},
+		{"(;C[a];C[b](;C[c])(;C[d]))", nil, func(tree *structures.GameTree) error { return tree.DeleteSubtree(1) }, "(;C[a])"},
+		{"(;C[a](;C[b])(;C[c])(;C[d]))", []int{1}, func(tree *structures.GameTree) error { return tree.DeleteSubtree(0) }, "(;C[a](;C[b])(;C[d]))"},
+		{"(;C[a](;C[b];C[e])(;C[c]))", []int{0}, func(tree *structures.GameTree) error { return tree.DeleteSubtree(1) }, "(;C[a](;C[b])(;C[c]))"},
+		{"(;C[a](;C[b])(;C[c])(;C[d]))", []int{2}, func(tree *structures.GameTree) error {
+			tree.Promote()
+			return nil
+		}, "(;C[a](;C[d])(;C[b])(;C[c]))"},
+		{"(;C[a](;C[b])(;C[c](;C[d])(;C[e])))", []int{1, 1}, func(tree *structures.GameTree) error {
+			tree.PromoteToMainLine()
+			return nil
+		}, "(;C[a](;C[c](;C[e])(;C[d]))(;C[b]))"},
+		{"(;C[a](;C[b])(;C[c])(;C[d]))", nil, func(tree *structures.GameTree) error { return tree.MoveChild(0, 2) }, "(;C[a](;C[c])(;C[d])(;C[b]))"},
+		{"(;C[a](;C[b])(;C[c])(;C[d]))", nil, func(tree *structures.GameTree) error { return tree.MoveChild(2, 1) }, "(;C[a](;C[b])(;C[d])(;C[c]))"},
+		{"(;C[a](;C[b](;C[c](;C[d])(;C[e](;C[f])))))", nil, func(tree *structures.GameTree) error {
+			if merged := tree.MergeSingleChildren(); merged != 3 {
+				return fmt.Errorf("expected 3 merged trees, found %d", merged)
+			}
+			return nil
+		}, "(;C[a];C[b];C[c](;C[d])(;C[e];C[f]))"},
+	}
+
+	for i, current := range editMatrix {
+		root := sgftest.ParseGameTree(t, current.raw)
+		tree := root
+		for _, variation := range current.variations {
+			tree = tree.Children[variation]
+		}
+
+		if err := current.edit(tree); err != nil {
+			t.Errorf("Test %d returned error! %s", i, err.Error())
+			continue
+		}
+		if root.String() != current.expected {
+			t.Errorf("Test %d: expected %s, found %s", i, current.expected, root.String())
+		}
+		if err := checkParents(root, nil); err != nil {
+			t.Errorf("Test %d: %s", i, err.Error())
+		}
+	}
+}
+
+func TestEditNeg(t *testing.T) {
+	tree := sgftest.ParseGameTree(t, "(;C[a](;C[b])(;C[c]))")
+	move := structures.Node{Properties: []structures.Property{property("C", "new")}}
+
+	if err := tree.InsertAfter(1, move); !errors.Is(err, structures.NodeIndexError) {
+		t.Errorf("Expected NodeIndexError, found %v", err)
+	}
+	if _, err := tree.Split(-1); !errors.Is(err, structures.NodeIndexError) {
+		t.Errorf("Expected NodeIndexError, found %v", err)
+	}
+	if _, err := tree.AddVariation(0); !errors.Is(err, structures.EmptyGameTreeError) {
+		t.Errorf("Expected EmptyGameTreeError, found %v", err)
+	}
+	if err := tree.DeleteSubtree(0); !errors.Is(err, structures.DeleteRootError) {
+		t.Errorf("Expected DeleteRootError, found %v", err)
+	}
+	if err := tree.MoveChild(0, 2); !errors.Is(err, structures.VariationIndexError) {
+		t.Errorf("Expected VariationIndexError, found %v", err)
+	}
+
+	if tree.String() != "(;C[a](;C[b])(;C[c]))" {
+		t.Errorf("Expected the tree to be unchanged, found %s", tree)
+	}
+}
+
+func TestClone(t *testing.T) {
+	collection := &structures.Collection{GameTrees: []*structures.GameTree{sgftest.ParseGameTree(t, "(;C[a]AB[aa][bb](;B[cc])(;B[dd];W[ee]))")}, Charset: "Latin1"}
+	clone := collection.Clone()
+
+	if !clone.Equal(*collection, structures.EqualOptions{}) || clone.String() != collection.String() || clone.Charset != "Latin1" {
+		t.Fatalf("Expected an equal clone, found %s", clone)
+	}
+	if err := checkParents(clone.GameTrees[0], nil); err != nil {
+		t.Errorf("%s", err.Error())
+	}
+
+	// changing the clone does not change the original
+	cloneTree := clone.GameTrees[0]
+	cloneTree.Sequence.Nodes[0].Properties[1].Values[0] = "zz"
+	cloneTree.Children[1].Sequence.Nodes[0].Properties[0].Ident = "W"
+	cloneTree.Children = cloneTree.Children[:1]
+	if collection.String() != "(;C[a]AB[aa][bb](;B[cc])(;B[dd];W[ee]))" {
+		t.Errorf("Expected the original to be unchanged, found %s", collection)
+	}
+
+	// a cloned variation is a top level tree
+	variation := collection.GameTrees[0].Children[1].Clone()
+	if variation.Parent != nil || variation.String() != "(;B[dd];W[ee])" {
+		t.Errorf("Unexpected clone of a variation %s", variation)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	type equalStruct struct {
+		first   string
+		second  string
+		options structures.EqualOptions
+		equal   bool
+	}
+
+	var equalMatrix = []equalStruct{
+		{"(;C[a];B[aa])", "(;C[a];B[aa])", structures.EqualOptions{}, true},
+		{"(;C[a];B[aa])", "(;C[a];B[bb])", structures.EqualOptions{}, false},
+		{"(;C[a];B[aa])", "(;C[a];B[aa];W[bb])", structures.EqualOptions{}, false},
+		{"(;C[a](;B[aa])(;B[bb]))", "(;C[a](;B[aa])(;B[bb]))", structures.EqualOptions{}, true},
+		{"(;C[a](;B[aa])(;B[bb]))", "(;C[a](;B[bb])(;B[aa]))", structures.EqualOptions{}, false},
+		{"(;C[a](;B[aa]))", "(;C[a];B[aa])", structures.EqualOptions{}, false},
+		{"(;C[a]GN[b])", "(;GN[b]C[a])", structures.EqualOptions{}, false},
+		{"(;C[a]GN[b])", "(;GN[b]C[a])", structures.EqualOptions{IgnorePropertyOrder: true}, true},
+		{"(;C[a]GN[b])", "(;GN[b]C[c])", structures.EqualOptions{IgnorePropertyOrder: true}, false},
+		{"(;AB[aa][bb])", "(;AB[bb][aa])", structures.EqualOptions{}, false},
+		{"(;AB[aa][bb])", "(;AB[bb][aa])", structures.EqualOptions{IgnoreValueOrder: true}, true},
+		{"(;C[a  b\n c ])", "(;C[a b c])", structures.EqualOptions{}, false},
+		{"(;C[a  b\n c ])", "(;C[a b c])", structures.EqualOptions{IgnoreWhitespace: true}, true},
+		{"(;C[ab])", "(;C[a b])", structures.EqualOptions{IgnoreWhitespace: true}, false},
+	}
+
+	for i, current := range equalMatrix {
+		first, second := sgftest.ParseGameTree(t, current.first), sgftest.ParseGameTree(t, current.second)
+		if first.Equal(second, current.options) != current.equal {
+			t.Errorf("Test %d: expected Equal to be %v for %s and %s", i, current.equal, current.first, current.second)
+		}
+		if second.Equal(first, current.options) != current.equal {
+			t.Errorf("Test %d: expected Equal to be symmetric", i)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	type pathStruct struct {
+		tree  int
+		steps []int
+		path  string
+	}
+
+	var pathMatrix = []pathStruct{
+		{0, nil, "0.0"},
+		{1, []int{0, 0, 0}, "1.3"},
+		{0, []int{0, 0, 2, 0, 0}, "0.2-2.3"},
+		{0, []int{1, 1}, "0.0-1.1-1.1"},
+		{0, []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0}, "0.12-2.5"},
+	}
+
+	for i, current := range pathMatrix {
+		if path := structures.FormatPath(current.tree, current.steps); path != current.path {
+			t.Errorf("Test %d: expected %s, found %s", i, current.path, path)
+		}
+		tree, steps, err := structures.ParsePath(current.path)
+		if err != nil {
+			t.Errorf("Test %d: ParsePath returned error! %s", i, err.Error())
+			continue
+		}
+		if tree != current.tree || !slices.Equal(steps, current.steps) {
+			t.Errorf("Test %d: expected %d %v, found %d %v", i, current.tree, current.steps, tree, steps)
+		}
+	}
+
+	for i, path := range []string{"", "0", "a.1", "0.-1", "0.1-", "0.1-1.0", "0.1-1", "-1.0", "0.1-0.1", "0.0-1.1-0.2", "0.01", "0.+1", "+0.1", "0.0-1.01", "00.1", "0.1-01.1"} {
+		if _, _, err := structures.ParsePath(path); !errors.Is(err, structures.PathError) {
+			t.Errorf("Test %d: expected a path error for %q, found %v", i, path, err)
+		}
+	}
+}
+
+func TestNodeAt(t *testing.T) {
+	collection := &structures.Collection{GameTrees: []*structures.GameTree{
+		sgftest.ParseGameTree(t, "(;C[a];C[b](;C[c];C[d])(;C[e](;C[f])(;C[g];C[h])))"),
+		sgftest.ParseGameTree(t, "(;C[i];C[j])"),
+	}}
+	collection.AssignIDs()
+
+	type nodeStruct struct {
+		path    string
+		comment string
+		id      int
+	}
+
+	var nodeMatrix = []nodeStruct{
+		{"0.0", "a", 1},
+		{"0.3", "d", 4},
+		{"0.1-1.1", "e", 5},
+		{"0.1-1.2", "f", 6},
+		{"0.1-1.1-1.2", "h", 8},
+		{"1.1", "j", 10},
+	}
+
+	for i, current := range nodeMatrix {
+		node, err := collection.NodeAt(current.path)
+		if err != nil {
+			t.Errorf("Test %d: NodeAt returned error! %s", i, err.Error())
+			continue
+		}
+		if comment(node) != current.comment || node.Id != current.id {
+			t.Errorf("Test %d: expected C[%s] with Id %d, found %s with Id %d", i, current.comment, current.id, node, node.Id)
+		}
+
+		byID, found := collection.NodeByID(current.id)
+		if !found || byID != node {
+			t.Errorf("Test %d: expected NodeByID to find %s, found %v", i, node, byID)
+		}
+		if path, found := collection.PathOf(node); !found || path != current.path {
+			t.Errorf("Test %d: expected PathOf to return %s, found %q", i, current.path, path)
+		}
+	}
+
+	for i, path := range []string{"2.0", "0.4", "0.1-2.1", "0.2-1.3", "1.0-1.1", "x"} {
+		if _, err := collection.NodeAt(path); !errors.Is(err, structures.PathError) {
+			t.Errorf("Test %d: expected a path error for %s, found %v", i, path, err)
+		}
+	}
+	if _, found := collection.NodeByID(11); found {
+		t.Errorf("Expected no node with Id 11")
+	}
+	if _, found := collection.PathOf(&structures.Node{}); found {
+		t.Errorf("Expected no path for a node outside the collection")
+	}
+}
@@ -0,0 +1,144 @@
+package structures
+
+import (
+	"strings"
+	"unicode"
+)
+
+const escapeChar = '\\'
+
+// NormalizeValue converts the raw text found between the brackets of a value to the value itself:
+// escape characters are removed, escaped line breaks ("soft line breaks") are removed and tabs become spaces.
+// A soft line break may consist of CR, LF, CRLF or LFCR. Other line breaks are kept.
+func NormalizeValue(raw string) PropValue {
+	return normalize(raw, NoneValue)
+}
+
+// NormalizeValueFor converts the raw text of a value of the given property to the value, depending on the type of the
+// property: for Text values soft line breaks are removed and all other white space except line breaks becomes a space.
+// SimpleText values are handled the same way, but the remaining line breaks become spaces as well. The parts of
+// composed values are normalized separately. Other types (and unknown properties) are handled by NormalizeValue.
+func NormalizeValueFor(ident PropIdent, raw string) PropValue {
+	info, ok := LookupProperty(ident)
+	if !ok {
+		return NormalizeValue(raw)
+	}
+
+	if info.HasCompose() {
+		if left, right, found := splitCompose(raw); found {
+			return normalize(left, info.ComposeTypes[0]) + ":" + normalize(right, info.ComposeTypes[1])
+		}
+	}
+	return normalize(raw, info.Type)
+}
+
+func normalize(raw string, valueType ValueType) PropValue {
+	var value strings.Builder
+	runes := []rune(raw)
+	doEscape := false
+	isText := valueType == TextValue || valueType == SimpleTextValue
+
+	for i := 0; i < len(runes); i++ {
+		currRune := runes[i]
+
+		// enter escape only if we are not escaping already
+		if currRune == escapeChar && !doEscape {
+			doEscape = true
+			continue
+		}
+
+		isLineBreak := currRune == '\n' || currRune == '\r'
+
+		// replace tabs (and for texts all white space except line breaks) with spaces (as per spec)
+		if currRune == '\t' || isText && !isLineBreak && unicode.IsSpace(currRune) {
+			currRune = ' '
+		}
+
+		if isLineBreak {
+			// a CRLF or LFCR sequence is a single line break
+			if i+1 < len(runes) && isLineBreakPair(currRune, runes[i+1]) {
+				i++
+				if !doEscape && valueType != SimpleTextValue {
+					value.WriteRune(currRune)
+					currRune = runes[i]
+				}
+			}
+
+			switch {
+			case doEscape:
+				// remove the new line if it's a "soft line break"
+				doEscape = false
+				continue
+			case valueType == SimpleTextValue:
+				currRune = ' '
+			}
+		}
+
+		value.WriteRune(currRune)
+		doEscape = false
+	}
+
+	return PropValue(value.String())
+}
+
+func isLineBreakPair(first, second rune) bool {
+	return (first == '\n' && second == '\r') || (first == '\r' && second == '\n')
+}
+
+// splitCompose splits the raw text of a value at the first unescaped ":"
+func splitCompose(raw string) (string, string, bool) {
+	doEscape := false
+	for i, r := range raw {
+		switch {
+		case doEscape:
+			doEscape = false
+		case r == escapeChar:
+			doEscape = true
+		case r == ':':
+			return raw[:i], raw[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// Compose splits the single value of a Compose property (e.g. LB[dd:A], AP[Primiview:3.1]) into its parts.
+// Only an unescaped ":" separates the parts, so escaped colons within them are preserved. It returns false if the
+// property does not have exactly one value or the value is not composed.
+func (prop Property) Compose() (left, right string, ok bool) {
+	if len(prop.Values) != 1 {
+		return "", "", false
+	}
+	return prop.composeAt(0)
+}
+
+// composeAt splits the i-th value of the property. The raw value is used if it's available and still matches the
+// value - otherwise the value is split at its first ":".
+func (prop Property) composeAt(i int) (string, string, bool) {
+	if raw, isRaw, ok := prop.rawAt(i); ok {
+		left, right, found := splitCompose(string(raw))
+		if !found || isRaw {
+			return left, right, found
+		}
+		info, _ := LookupProperty(prop.Ident)
+		return string(normalize(left, info.ComposeTypes[0])), string(normalize(right, info.ComposeTypes[1])), true
+	}
+
+	return strings.Cut(string(prop.Values[i]), ":")
+}
+
+// rawAt returns the raw text of the i-th value if it's available and the value was not changed since parsing.
+// isRaw is true if the value itself is the raw text (see parser.RawValues).
+func (prop Property) rawAt(i int) (raw PropValue, isRaw bool, ok bool) {
+	if i >= len(prop.Raw) || i >= len(prop.Values) {
+		return "", false, false
+	}
+
+	raw = prop.Raw[i]
+	if prop.Values[i] == raw {
+		return raw, true, true
+	}
+	if NormalizeValueFor(prop.Ident, string(raw)) == prop.Values[i] {
+		return raw, false, true
+	}
+	return "", false, false
+}
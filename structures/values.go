@@ -0,0 +1,334 @@
+package structures
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValueType is the type of a PropValue as defined in FF[4]
+type ValueType int
+
+const (
+	NoneValue ValueType = iota
+	NumberValue
+	RealValue
+	DoubleValue
+	ColorValue
+	SimpleTextValue
+	TextValue
+	PointValue
+	MoveValue
+	StoneValue
+	ComposeValue
+)
+
+func (valueType ValueType) String() string {
+	switch valueType {
+	case NoneValue:
+		return "None"
+	case NumberValue:
+		return "Number"
+	case RealValue:
+		return "Real"
+	case DoubleValue:
+		return "Double"
+	case ColorValue:
+		return "Color"
+	case SimpleTextValue:
+		return "SimpleText"
+	case TextValue:
+		return "Text"
+	case PointValue:
+		return "Point"
+	case MoveValue:
+		return "Move"
+	case StoneValue:
+		return "Stone"
+	case ComposeValue:
+		return "Compose"
+	}
+	return fmt.Sprintf("ValueType(%d)", int(valueType))
+}
+
+// Color is the value of Color properties (e.g. PL)
+type Color byte
+
+const (
+	Black Color = 'B'
+	White Color = 'W'
+)
+
+func (color Color) String() string {
+	return string(color)
+}
+
+// Opponent returns the other color
+func (color Color) Opponent() Color {
+	if color == Black {
+		return White
+	}
+	return Black
+}
+
+// Point is a point on the go board. X is the column and Y is the row, both starting from 0 at the upper left corner.
+// As per FF[4] "a".."z" encode 0..25 and "A".."Z" encode 26..51.
+type Point struct {
+	X int
+	Y int
+}
+
+func (point Point) String() string {
+	return string(pointLetter(point.X)) + string(pointLetter(point.Y))
+}
+
+func pointLetter(coord int) rune {
+	if coord < 26 {
+		return rune('a' + coord)
+	}
+	return rune('A' + coord - 26)
+}
+
+func pointCoord(letter rune) (int, bool) {
+	switch {
+	case letter >= 'a' && letter <= 'z':
+		return int(letter - 'a'), true
+	case letter >= 'A' && letter <= 'Z':
+		return int(letter-'A') + 26, true
+	}
+	return 0, false
+}
+
+// ParsePoint converts a go point (e.g. "pd") to a Point
+func ParsePoint(value PropValue) (Point, error) {
+	runes := []rune(string(value))
+	if len(runes) != 2 {
+		return Point{}, fmt.Errorf("point must consist of two letters, found %q", string(value))
+	}
+
+	x, okX := pointCoord(runes[0])
+	y, okY := pointCoord(runes[1])
+	if !okX || !okY {
+		return Point{}, fmt.Errorf("invalid point %q", string(value))
+	}
+	return Point{X: x, Y: y}, nil
+}
+
+// ValueError is returned when a property value can not be converted to the requested type
+type ValueError struct {
+	Ident PropIdent
+	Value PropValue
+	// Index is the index of Value among the values of the property
+	Index int
+	// Values are all values of the property, if the error is about the values as a whole (e.g. their number)
+	Values []PropValue
+	Type   ValueType
+	Msg    string
+}
+
+func (e *ValueError) Error() string {
+	value := fmt.Sprintf("[%s]", e.Value)
+	switch {
+	case e.Values != nil:
+		value = ""
+		for _, v := range e.Values {
+			value += fmt.Sprintf("[%s]", v)
+		}
+	case e.Index > 0:
+		value += fmt.Sprintf(" (value %d)", e.Index+1)
+	}
+	return fmt.Sprintf("%s%s is not a valid %s: %s", e.Ident, value, e.Type, e.Msg)
+}
+
+// singleValue returns the only value of the property or a ValueError
+func (prop Property) singleValue(valueType ValueType) (PropValue, error) {
+	if len(prop.Values) != 1 {
+		return "", &ValueError{Ident: prop.Ident, Values: prop.Values, Type: valueType, Msg: fmt.Sprintf("expected a single value, found %d", len(prop.Values))}
+	}
+	return prop.Values[0], nil
+}
+
+// checkConvertible returns a ValueError unless the (known) property holds values of the given or a compatible type.
+// The values of unknown properties can be converted to any type.
+func (prop Property) checkConvertible(valueType ValueType, compatible ...ValueType) error {
+	info, ok := LookupProperty(prop.Ident)
+	if !ok {
+		return nil
+	}
+
+	for _, t := range append(compatible, valueType) {
+		if info.Type == t {
+			return nil
+		}
+	}
+	return &ValueError{Ident: prop.Ident, Values: prop.Values, Type: valueType, Msg: fmt.Sprintf("%s holds %s values", prop.Ident, info.Type)}
+}
+
+func (prop Property) convert(valueType ValueType, compatible ...ValueType) (PropValue, error) {
+	value, err := prop.singleValue(valueType)
+	if err != nil {
+		return "", err
+	}
+	if err := prop.checkConvertible(valueType, compatible...); err != nil {
+		err.(*ValueError).Value, err.(*ValueError).Values = value, nil
+		return "", err
+	}
+	return value, nil
+}
+
+// AsNumber returns the value of a Number property (e.g. HA, MN)
+func (prop Property) AsNumber() (int, error) {
+	value, err := prop.convert(NumberValue, DoubleValue)
+	if err != nil {
+		return 0, err
+	}
+
+	if !isNumber(string(value)) {
+		return 0, &ValueError{Ident: prop.Ident, Value: value, Type: NumberValue, Msg: "not a number"}
+	}
+	number, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, &ValueError{Ident: prop.Ident, Value: value, Type: NumberValue, Msg: err.Error()}
+	}
+	return number, nil
+}
+
+// AsReal returns the value of a Real property (e.g. KM, V)
+func (prop Property) AsReal() (float64, error) {
+	value, err := prop.convert(RealValue, NumberValue)
+	if err != nil {
+		return 0, err
+	}
+
+	if !isReal(string(value)) {
+		return 0, &ValueError{Ident: prop.Ident, Value: value, Type: RealValue, Msg: "not a real number"}
+	}
+	number, err := strconv.ParseFloat(string(value), 64)
+	if err != nil {
+		return 0, &ValueError{Ident: prop.Ident, Value: value, Type: RealValue, Msg: err.Error()}
+	}
+	return number, nil
+}
+
+// AsDouble returns the value of a Double property (e.g. GB, TE). The result is either 1 (normal) or 2 (emphasized).
+func (prop Property) AsDouble() (int, error) {
+	value, err := prop.convert(DoubleValue)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value {
+	case "1":
+		return 1, nil
+	case "2":
+		return 2, nil
+	}
+	return 0, &ValueError{Ident: prop.Ident, Value: value, Type: DoubleValue, Msg: "must be 1 or 2"}
+}
+
+// AsColor returns the value of a Color property (e.g. PL)
+func (prop Property) AsColor() (Color, error) {
+	value, err := prop.convert(ColorValue)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value {
+	case "B":
+		return Black, nil
+	case "W":
+		return White, nil
+	}
+	return 0, &ValueError{Ident: prop.Ident, Value: value, Type: ColorValue, Msg: "must be B or W"}
+}
+
+// AsPoint returns the value of a Point, Move or Stone property (e.g. B, W). A pass move can not be converted to a Point.
+func (prop Property) AsPoint() (Point, error) {
+	value, err := prop.convert(PointValue, MoveValue, StoneValue)
+	if err != nil {
+		return Point{}, err
+	}
+
+	point, err := ParsePoint(value)
+	if err != nil {
+		return Point{}, &ValueError{Ident: prop.Ident, Value: value, Type: PointValue, Msg: err.Error()}
+	}
+	return point, nil
+}
+
+// AsCompose returns both parts of a Compose value (e.g. LB, AP)
+func (prop Property) AsCompose() (PropValue, PropValue, error) {
+	value, err := prop.singleValue(ComposeValue)
+	if err != nil {
+		return "", "", err
+	}
+	if info, ok := LookupProperty(prop.Ident); ok && info.Type != ComposeValue && !info.HasCompose() {
+		return "", "", &ValueError{Ident: prop.Ident, Value: value, Type: ComposeValue, Msg: fmt.Sprintf("%s holds %s values", prop.Ident, info.Type)}
+	}
+
+	left, right, found := prop.Compose()
+	if !found {
+		return "", "", &ValueError{Ident: prop.Ident, Value: value, Type: ComposeValue, Msg: "missing ':'"}
+	}
+	return PropValue(left), PropValue(right), nil
+}
+
+// AsText returns the value of any single valued property as text
+func (prop Property) AsText() (string, error) {
+	value, err := prop.singleValue(TextValue)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+func isDigits(value string) bool {
+	if len(value) == 0 {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Number = [("+"|"-")] Digit { Digit }
+func isNumber(value string) bool {
+	if len(value) > 0 && (value[0] == '+' || value[0] == '-') {
+		value = value[1:]
+	}
+	return isDigits(value)
+}
+
+// Real = Number ["." Digit { Digit }]
+func isReal(value string) bool {
+	number, fraction, found := strings.Cut(value, ".")
+	return isNumber(number) && (!found || isDigits(fraction))
+}
+
+// isValueOfType reports whether the value is a valid value of the (non compose) type
+func isValueOfType(value PropValue, valueType ValueType) bool {
+	switch valueType {
+	case NoneValue:
+		return value == ""
+	case NumberValue:
+		return isNumber(string(value))
+	case RealValue:
+		return isReal(string(value))
+	case DoubleValue:
+		return value == "1" || value == "2"
+	case ColorValue:
+		return value == "B" || value == "W"
+	case SimpleTextValue, TextValue:
+		return true
+	case PointValue, StoneValue:
+		_, err := ParsePoint(value)
+		return err == nil
+	case MoveValue:
+		_, err := ParsePoint(value)
+		return value == "" || err == nil
+	}
+	return false
+}
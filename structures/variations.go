@@ -0,0 +1,71 @@
+package structures
+
+// MainLine returns the nodes of the main line of the tree - the sequence followed by the main line of the first child
+// at every branch point. The nodes are copies, so they can be changed without affecting the tree.
+func (tree *GameTree) MainLine() []Node {
+	var nodes []Node
+	for current := tree; current != nil; {
+		nodes = append(nodes, copyNodes(current.Sequence.Nodes)...)
+
+		if len(current.Children) == 0 {
+			break
+		}
+		current = current.Children[0]
+	}
+	return nodes
+}
+
+// Lines returns every variation of the tree as a list of nodes from the root to a leaf. The main line comes first.
+// The nodes are copies, so they can be changed without affecting the tree.
+func (tree *GameTree) Lines() [][]Node {
+	var lines [][]Node
+
+	var walk func(tree *GameTree, prefix []Node)
+	walk = func(tree *GameTree, prefix []Node) {
+		// copy the prefix, so that sibling variations do not share the backing array
+		line := append(append([]Node(nil), prefix...), copyNodes(tree.Sequence.Nodes)...)
+		if len(tree.Children) == 0 {
+			lines = append(lines, line)
+			return
+		}
+		for _, child := range tree.Children {
+			walk(child, line)
+		}
+	}
+	walk(tree, nil)
+
+	return lines
+}
+
+// StripVariations returns a new collection, holding only the main line of each game tree
+func (collection Collection) StripVariations() *Collection {
+	result := &Collection{Charset: collection.Charset}
+	for _, tree := range collection.GameTrees {
+		result.GameTrees = append(result.GameTrees, linearTree(tree.MainLine()))
+	}
+	return result
+}
+
+// SplitVariations returns a new collection, in which every variation of every game tree is a separate linear game
+func (collection Collection) SplitVariations() *Collection {
+	result := &Collection{Charset: collection.Charset}
+	for _, tree := range collection.GameTrees {
+		for _, line := range tree.Lines() {
+			result.GameTrees = append(result.GameTrees, linearTree(line))
+		}
+	}
+	return result
+}
+
+func linearTree(nodes []Node) *GameTree {
+	return &GameTree{Sequence: Sequence{Nodes: nodes}}
+}
+
+// copyNodes copies the nodes together with their properties and values
+func copyNodes(nodes []Node) []Node {
+	copied := make([]Node, 0, len(nodes))
+	for _, node := range nodes {
+		copied = append(copied, node.Clone())
+	}
+	return copied
+}
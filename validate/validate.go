@@ -0,0 +1,313 @@
+// Package validate checks parsed collections against the FF[4] rules.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/makpoc/sgfparser/goboard"
+	"github.com/makpoc/sgfparser/parser"
+	"github.com/makpoc/sgfparser/structures"
+)
+
+// Severity tells how serious a finding is
+type Severity int
+
+const (
+	// Warning - the collection is valid, but probably not what was intended (e.g. a missing FF property)
+	Warning Severity = iota
+	// Error - the collection violates the FF[4] specification
+	Error
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(severity))
+}
+
+// MarshalText writes the severity as "warning" or "error" in JSON
+func (severity Severity) MarshalText() ([]byte, error) {
+	return []byte(severity.String()), nil
+}
+
+// Rule identifies the check, which produced a finding
+type Rule string
+
+const (
+	MisplacedRootProperty Rule = "root-property"
+	DuplicateProperty     Rule = "duplicate-property"
+	MixedMoveSetup        Rule = "move-setup"
+	OutOfBoard            Rule = "out-of-board"
+	MissingProperty       Rule = "missing-property"
+	InvalidValue          Rule = "invalid-value"
+	Syntax                Rule = "syntax"
+)
+
+// Finding is a single problem found in a collection
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Rule     Rule     `json:"rule"`
+	// Tree is the index of the game tree in the input. It's the index in the collection, unless ValidateParsed reports
+	// skipped trees before it.
+	Tree int `json:"tree"`
+	// Path is the path of the node within the game tree (see NodePath)
+	Path     string               `json:"path"`
+	Property structures.PropIdent `json:"property,omitempty"`
+	Msg      string               `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: node %s: %s (%s)", f.Severity, f.Path, f.Msg, f.Rule)
+}
+
+// HasErrors reports whether any of the findings is an Error
+func HasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks all game trees in the collection and returns the findings in document order
+func Validate(collection *structures.Collection) []Finding {
+	var findings []Finding
+	for i, tree := range collection.GameTrees {
+		findings = append(findings, ValidateGameTree(i, tree)...)
+	}
+	return findings
+}
+
+// ValidateParsed checks the collection like Validate and reports the game trees, which the parser skipped, as Error
+// findings: DuplicateProperty for duplicate properties and Syntax for everything else. The diagnostics are those
+// returned with the collection. The tree indexes and paths count the skipped trees, so they match the input, but not
+// the collection.
+func ValidateParsed(collection *structures.Collection, diagnostics []parser.Diagnostic) []Finding {
+	var skipped []parser.Diagnostic
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Action == parser.TreeSkipped {
+			skipped = append(skipped, diagnostic)
+		}
+	}
+
+	var findings []Finding
+	index := 0
+	for _, tree := range collection.GameTrees {
+		for ; len(skipped) > 0 && skipped[0].TreeIndex == index; index++ {
+			findings = append(findings, skippedTree(skipped[0]))
+			skipped = skipped[1:]
+		}
+		findings = append(findings, ValidateGameTree(index, tree)...)
+		index++
+	}
+	for _, diagnostic := range skipped {
+		findings = append(findings, skippedTree(diagnostic))
+	}
+	return findings
+}
+
+// skippedTree turns the diagnostic of a skipped game tree into a finding
+func skippedTree(diagnostic parser.Diagnostic) Finding {
+	finding := Finding{Severity: Error, Rule: Syntax, Tree: diagnostic.TreeIndex, Path: diagnostic.Path, Msg: diagnostic.Msg}
+	if finding.Path == "" {
+		finding.Path = NodePath(diagnostic.TreeIndex, nil)
+	}
+
+	var syntaxErr *parser.SyntaxError
+	if errors.Is(diagnostic.Err, parser.DuplicatePropertyError) && errors.As(diagnostic.Err, &syntaxErr) {
+		finding.Rule = DuplicateProperty
+		finding.Property = structures.PropIdent(syntaxErr.Msg)
+	}
+	return finding
+}
+
+// ValidateGameTree checks a single top level game tree. index is the index of the tree in its collection and is used
+// in the node paths.
+func ValidateGameTree(index int, tree *structures.GameTree) []Finding {
+	v := &validator{tree: index}
+	if len(tree.Sequence.Nodes) == 0 {
+		return nil
+	}
+
+	root := tree.Sequence.Nodes[0]
+	v.checkRoot(root)
+	v.walk(tree, nil, true)
+	return v.findings
+}
+
+// NodePath formats the path of a node, e.g. "0.12-2.5" (see structures.FormatPath). The paths of Validate can be
+// resolved with structures.Collection.NodeAt. Those of ValidateParsed refer to the input and can be resolved the same
+// way only if no game tree was skipped.
+func NodePath(tree int, steps []int) string {
+	return structures.FormatPath(tree, steps)
+}
+
+type validator struct {
+	tree     int
+	findings []Finding
+	// width and height of the board for point checks. 0 if the game is not go or the size is invalid.
+	width  int
+	height int
+}
+
+func (v *validator) add(severity Severity, rule Rule, steps []int, ident structures.PropIdent, msg string) {
+	v.findings = append(v.findings, Finding{Severity: severity, Rule: rule, Tree: v.tree, Path: NodePath(v.tree, steps), Property: ident, Msg: msg})
+}
+
+// checkRoot checks the presence of GM and FF and reads the board size
+func (v *validator) checkRoot(root structures.Node) {
+	game := 1
+	for _, ident := range []structures.PropIdent{"GM", "FF"} {
+		prop, found := root.Property(ident)
+		if !found {
+			v.add(Warning, MissingProperty, nil, ident, fmt.Sprintf("%s is missing in the root node", ident))
+			continue
+		}
+
+		number, err := prop.AsNumber()
+		if err != nil {
+			// reported as invalid value
+			continue
+		}
+		switch {
+		case ident == "GM":
+			game = number
+			if game != 1 {
+				v.add(Warning, InvalidValue, nil, ident, fmt.Sprintf("GM[%d] is not go, board checks skipped", game))
+			}
+		case ident == "FF" && number != 4:
+			v.add(Warning, InvalidValue, nil, ident, fmt.Sprintf("FF[%d] checked against the FF[4] rules", number))
+		}
+	}
+
+	if game != 1 {
+		return
+	}
+	width, height, err := goboard.BoardSize(root)
+	if err != nil {
+		// reported as invalid value
+		return
+	}
+	if width < 1 || height < 1 || width > goboard.MaxSize || height > goboard.MaxSize {
+		v.add(Error, InvalidValue, nil, "SZ", fmt.Sprintf("invalid board size %dx%d", width, height))
+		return
+	}
+	v.width, v.height = width, height
+}
+
+// walk checks the nodes of the tree and its children. steps is the path to the parent of the first node.
+func (v *validator) walk(tree *structures.GameTree, steps []int, isRoot bool) {
+	for i, node := range tree.Sequence.Nodes {
+		if !isRoot || i > 0 {
+			step := 0
+			if i == 0 && tree.Parent != nil {
+				step = childIndex(tree)
+			}
+			steps = append(steps, step)
+		}
+		v.checkNode(node, steps, isRoot && i == 0)
+	}
+
+	for _, child := range tree.Children {
+		v.walk(child, append([]int(nil), steps...), false)
+	}
+}
+
+func childIndex(tree *structures.GameTree) int {
+	for i, child := range tree.Parent.Children {
+		if child == tree {
+			return i
+		}
+	}
+	return 0
+}
+
+func (v *validator) checkNode(node structures.Node, steps []int, isRoot bool) {
+	if err := node.Check(isRoot); err != nil {
+		for _, err := range unjoin(err) {
+			switch {
+			case errors.Is(err, structures.MisplacedRootPropertyError):
+				v.add(Error, MisplacedRootProperty, steps, "", err.Error())
+			case errors.Is(err, structures.MixedMoveSetupError):
+				v.add(Error, MixedMoveSetup, steps, "", err.Error())
+			}
+		}
+	}
+
+	seen := make(map[structures.PropIdent]bool)
+	for _, prop := range node.Properties {
+		if seen[prop.Ident] {
+			v.add(Error, DuplicateProperty, steps, prop.Ident, fmt.Sprintf("%s appears more than once", prop.Ident))
+		}
+		seen[prop.Ident] = true
+
+		if err := prop.Check(); err != nil {
+			v.add(Error, InvalidValue, steps, prop.Ident, err.Error())
+			continue
+		}
+		v.checkPoints(prop, steps)
+	}
+}
+
+// checkPoints reports points outside the board declared with SZ
+func (v *validator) checkPoints(prop structures.Property, steps []int) {
+	if v.width == 0 {
+		return
+	}
+	info, ok := structures.LookupProperty(prop.Ident)
+	if !ok {
+		return
+	}
+
+	var points []structures.PropValue
+	switch {
+	case info.Type == structures.MoveValue:
+		// an empty value and "tt" on boards up to 19x19 are a pass
+		value := prop.Values[0]
+		if value == "" || value == "tt" && v.width <= 19 && v.height <= 19 {
+			return
+		}
+		points = prop.Values
+	case info.Type == structures.PointValue || info.Type == structures.StoneValue:
+		points = prop.Values
+	case info.Type == structures.ComposeValue && info.ComposeTypes[0] == structures.PointValue:
+		for _, value := range prop.Values {
+			left, right, _ := strings.Cut(string(value), ":")
+			points = append(points, structures.PropValue(left))
+			if info.ComposeTypes[1] == structures.PointValue {
+				points = append(points, structures.PropValue(right))
+			}
+		}
+	default:
+		return
+	}
+
+	if info.EmptyList && len(points) == 1 && points[0] == "" {
+		return
+	}
+	expanded, err := structures.ExpandPoints(points)
+	if err != nil {
+		v.add(Error, InvalidValue, steps, prop.Ident, err.Error())
+		return
+	}
+	for _, point := range expanded {
+		if point.X >= v.width || point.Y >= v.height {
+			v.add(Error, OutOfBoard, steps, prop.Ident, fmt.Sprintf("%s[%s] is outside the %dx%d board", prop.Ident, point, v.width, v.height))
+		}
+	}
+}
+
+// unjoin returns the errors joined with errors.Join
+func unjoin(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
@@ -0,0 +1,117 @@
+package validate_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/makpoc/sgfparser/internal/sgftest"
+	"github.com/makpoc/sgfparser/structures"
+	"github.com/makpoc/sgfparser/validate"
+)
+
+func TestValidate(t *testing.T) {
+	type findingStruct struct {
+		severity validate.Severity
+		rule     validate.Rule
+		path     string
+		ident    structures.PropIdent
+	}
+
+	type validateStruct struct {
+		raw      string
+		findings []findingStruct
+	}
+
+	var validateMatrix = []validateStruct{
+		{"(;FF[4]GM[1]SZ[9];B[aa];W[ii])", nil},
+		{"(;FF[4]GM[1];B[tt];W[])", nil},
+		{"(;FF[4]GM[1]SZ[19:9]AB[aa][ai:si])", nil},
+		{"(;SZ[9])", []findingStruct{
+			{validate.Warning, validate.MissingProperty, "0.0", "GM"},
+			{validate.Warning, validate.MissingProperty, "0.0", "FF"},
+		}},
+		{"(;FF[3]GM[2]SZ[9];B[zz])", []findingStruct{
+			{validate.Warning, validate.InvalidValue, "0.0", "GM"},
+			{validate.Warning, validate.InvalidValue, "0.0", "FF"},
+		}},
+		{"(;FF[4]GM[1]SZ[9];B[jj];W[aa]SZ[5];B[bb]AB[cc])", []findingStruct{
+			{validate.Error, validate.OutOfBoard, "0.1", "B"},
+			{validate.Error, validate.MisplacedRootProperty, "0.2", ""},
+			{validate.Error, validate.MixedMoveSetup, "0.3", ""},
+		}},
+		{"(;FF[4]GM[1]SZ[5](;B[aa])(;B[bb];W[cc](;B[dd])(;LB[ee:a][ff:b]AR[aa:gg])))", []findingStruct{
+			{validate.Error, validate.OutOfBoard, "0.0-1.2-1.1", "LB"},
+			{validate.Error, validate.OutOfBoard, "0.0-1.2-1.1", "AR"},
+		}},
+		{"(;FF[4]GM[1]SZ[60])", []findingStruct{
+			{validate.Error, validate.InvalidValue, "0.0", "SZ"},
+		}},
+		{"(;FF[4]GM[1]SZ[x];B[aa]MN[a])", []findingStruct{
+			{validate.Error, validate.InvalidValue, "0.0", "SZ"},
+			{validate.Error, validate.InvalidValue, "0.1", "MN"},
+		}},
+	}
+
+	for i, current := range validateMatrix {
+		findings := validate.Validate(sgftest.ParseCollection(t, current.raw))
+		if len(findings) != len(current.findings) {
+			t.Errorf("Test %d: expected %d findings, found %v", i, len(current.findings), findings)
+			continue
+		}
+		for j, finding := range findings {
+			expected := current.findings[j]
+			if finding.Severity != expected.severity || finding.Rule != expected.rule || finding.Path != expected.path || finding.Property != expected.ident {
+				t.Errorf("Test %d: expected %v, found %v", i, expected, finding)
+			}
+		}
+		if validate.HasErrors(findings) != (len(current.findings) > 0 && current.findings[len(current.findings)-1].severity == validate.Error) {
+			t.Errorf("Test %d: HasErrors returned %v", i, validate.HasErrors(findings))
+		}
+	}
+}
+
+func TestDuplicateProperty(t *testing.T) {
+	// the parser rejects duplicates, so build the collection by hand
+	collection := sgftest.ParseCollection(t, "(;FF[4]GM[1];B[aa])")
+	node := &collection.GameTrees[0].Sequence.Nodes[1]
+	node.Properties = append(node.Properties, node.Properties[0])
+
+	findings := validate.Validate(collection)
+	if len(findings) != 1 || findings[0].Rule != validate.DuplicateProperty || findings[0].Path != "0.1" {
+		t.Errorf("Expected a duplicate property, found %v", findings)
+	}
+}
+
+func TestNodePath(t *testing.T) {
+	type pathStruct struct {
+		tree  int
+		steps []int
+		path  string
+	}
+
+	var pathMatrix = []pathStruct{
+		{0, nil, "0.0"},
+		{1, []int{0, 0, 0}, "1.3"},
+		{0, []int{0, 0, 2, 0, 0}, "0.2-2.3"},
+		{0, []int{1, 1}, "0.0-1.1-1.1"},
+	}
+
+	for i, current := range pathMatrix {
+		if path := validate.NodePath(current.tree, current.steps); path != current.path {
+			t.Errorf("Test %d: expected %s, found %s", i, current.path, path)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	findings := validate.Validate(sgftest.ParseCollection(t, "(;GM[1]FF[4]SZ[3];B[dd])"))
+
+	output, err := json.Marshal(findings)
+	if err != nil {
+		t.Fatalf("Marshal returned error! %s", err.Error())
+	}
+	expected := `[{"severity":"error","rule":"out-of-board","tree":0,"path":"0.1","property":"B","message":"B[dd] is outside the 3x3 board"}]`
+	if string(output) != expected {
+		t.Errorf("Expected %s, found %s", expected, output)
+	}
+}
@@ -0,0 +1,174 @@
+// Package writer encodes collections back to SGF.
+package writer
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/makpoc/sgfparser/charset"
+	"github.com/makpoc/sgfparser/structures"
+)
+
+// Options configure the SGF output
+type Options struct {
+	// Compact writes every game tree on a single line, without any white space between the elements
+	Compact bool
+	// LineWidth is the maximum length of a line. Lines are broken only between properties and between values,
+	// so a single long value may exceed it. 0 disables wrapping.
+	LineWidth int
+	// CompressPointLists writes point lists (e.g. AB, AE, TR) as compressed rectangles (e.g. AB[aa:cc])
+	CompressPointLists bool
+	// Charset is the character set of the output (e.g. "ISO-8859-1"). Empty means the Charset of the collection, which
+	// is the one it was parsed from. The CA property is not changed, so it should declare the same character set.
+	Charset string
+}
+
+// DefaultOptions put each node and variation on its own line and wrap lines at 80 characters
+var DefaultOptions = Options{LineWidth: 80}
+
+// Encode writes the collection to w in SGF format. Values are escaped, so that parsing the output results in the same
+// collection.
+func Encode(w io.Writer, collection *structures.Collection, options Options) error {
+	name := options.Charset
+	if name == "" {
+		name = collection.Charset
+	}
+	if charset.IsUTF8(name) {
+		return encode(w, collection, options)
+	}
+
+	cs, err := charset.Lookup(name)
+	if err != nil {
+		return err
+	}
+	converted := cs.NewEncoder(w)
+	err = encode(converted, collection, options)
+	if closer, ok := converted.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func encode(w io.Writer, collection *structures.Collection, options Options) error {
+	enc := &encoder{writer: w, options: options}
+
+	for i, tree := range collection.GameTrees {
+		if i > 0 && !options.Compact {
+			enc.newLine()
+		}
+		enc.gameTree(tree)
+	}
+	if !options.Compact {
+		enc.newLine()
+	}
+
+	return enc.err
+}
+
+// EncodeGameTree writes a single game tree to w in SGF format. The output is UTF-8 unless options.Charset is set.
+func EncodeGameTree(w io.Writer, tree *structures.GameTree, options Options) error {
+	return Encode(w, &structures.Collection{GameTrees: []*structures.GameTree{tree}}, options)
+}
+
+// encoder keeps track of the current column and the first write error
+type encoder struct {
+	writer  io.Writer
+	options Options
+	column  int
+	err     error
+}
+
+func (enc *encoder) write(s string) {
+	if enc.err != nil {
+		return
+	}
+
+	_, enc.err = io.WriteString(enc.writer, s)
+
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		enc.column = utf8.RuneCountInString(s[i+1:])
+	} else {
+		enc.column += utf8.RuneCountInString(s)
+	}
+}
+
+func (enc *encoder) newLine() {
+	enc.write("\n")
+}
+
+// token writes s, breaking the line before it if it would not fit in the line width
+func (enc *encoder) token(s string) {
+	if !enc.options.Compact && enc.options.LineWidth > 0 && enc.column > 0 {
+		width := utf8.RuneCountInString(s)
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			width = utf8.RuneCountInString(s[:i])
+		}
+		if enc.column+width > enc.options.LineWidth {
+			enc.newLine()
+		}
+	}
+	enc.write(s)
+}
+
+func (enc *encoder) gameTree(tree *structures.GameTree) {
+	enc.write(string(structures.GameTreeStart))
+
+	for i, node := range tree.Sequence.Nodes {
+		if i > 0 && !enc.options.Compact {
+			enc.newLine()
+		}
+		enc.node(node)
+	}
+
+	for _, child := range tree.Children {
+		if !enc.options.Compact {
+			enc.newLine()
+		}
+		enc.gameTree(child)
+	}
+
+	enc.write(string(structures.GameTreeEnd))
+}
+
+func (enc *encoder) node(node structures.Node) {
+	enc.write(string(structures.NodeSeparator))
+
+	for _, prop := range node.Properties {
+		if enc.options.CompressPointLists {
+			prop = compressed(prop)
+		}
+
+		values := prop.EscapedValues()
+		if len(values) == 0 {
+			continue
+		}
+
+		// the identifier and its first value are kept on the same line
+		enc.token(string(prop.Ident) + bracket(values[0]))
+		for _, value := range values[1:] {
+			enc.token(bracket(value))
+		}
+	}
+}
+
+func bracket(value string) string {
+	return string(structures.PropertyValueStart) + value + string(structures.PropertyValueEnd)
+}
+
+// compressed returns the property with its points compressed to rectangles, if it is a valid point list
+func compressed(prop structures.Property) structures.Property {
+	info, ok := structures.LookupProperty(prop.Ident)
+	if !ok || !info.List || (info.Type != structures.PointValue && info.Type != structures.StoneValue) {
+		return prop
+	}
+
+	points, err := prop.AsPoints()
+	if err != nil || len(points) == 0 {
+		return prop
+	}
+	prop.Values = structures.CompressPoints(points)
+	return prop
+}
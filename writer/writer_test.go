@@ -0,0 +1,145 @@
+package writer_test
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/makpoc/sgfparser/internal/sgftest"
+	"github.com/makpoc/sgfparser/parser"
+	"github.com/makpoc/sgfparser/structures"
+	"github.com/makpoc/sgfparser/writer"
+)
+
+func encode(t *testing.T, collection *structures.Collection, options writer.Options) string {
+	var output bytes.Buffer
+	if err := writer.Encode(&output, collection, options); err != nil {
+		t.Fatalf("Failed to encode: %s", err.Error())
+	}
+	return output.String()
+}
+
+func TestEncode(t *testing.T) {
+	type encodeStruct struct {
+		raw     string
+		options writer.Options
+		encoded string
+	}
+
+	var encodeMatrix = []encodeStruct{
+		{"(;FF[4]C[root](;B[aa];W[bb])(;B[cc]))", writer.Options{Compact: true}, "(;FF[4]C[root](;B[aa];W[bb])(;B[cc]))"},
+		{"(;FF[4]C[root](;B[aa];W[bb])(;B[cc]))", writer.Options{}, "(;FF[4]C[root]\n(;B[aa]\n;W[bb])\n(;B[cc]))\n"},
+		{"(;C[a])(;C[b])", writer.Options{}, "(;C[a])\n(;C[b])\n"},
+		{"(;C[a\\]b\\\\c:d]LB[dd:a\\:b])", writer.Options{Compact: true}, "(;C[a\\]b\\\\c:d]LB[dd:a\\:b])"},
+		{"(;AB[aa][bb][cc]C[some comment])", writer.Options{LineWidth: 12}, "(;AB[aa][bb]\n[cc]\nC[some comment])\n"},
+		{"(;AP[Prim\\:iview:3.1]LB[dd:a\\:b])", writer.Options{Compact: true}, "(;AP[Prim\\:iview:3.1]LB[dd:a\\:b])"},
+		{"(;AB[aa][ba][ab][bb][cc]C[aa][ba]VW[])", writer.Options{Compact: true, CompressPointLists: true}, "(;AB[aa:bb][cc]C[aa][ba]VW[])"},
+	}
+
+	for i, current := range encodeMatrix {
+		encoded := encode(t, sgftest.ParseCollection(t, current.raw), current.options)
+		if encoded != current.encoded {
+			t.Errorf("Test %d: expected %q, found %q", i, current.encoded, encoded)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	files := []string{"sample1.sgf", "sample2.sgf", "sample3.sgf", "sample_big.sgf"}
+	options := []writer.Options{writer.DefaultOptions, {Compact: true}, {LineWidth: 20}}
+
+	compressedFile := "sample_big.sgf"
+	compressedOptions := writer.Options{Compact: true, CompressPointLists: true}
+
+	for _, file := range files {
+		raw, err := os.ReadFile("../files/" + file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %s", file, err.Error())
+		}
+		original := sgftest.ParseCollection(t, string(raw))
+
+		for _, option := range options {
+			encoded := encode(t, original, option)
+			if parsed := sgftest.ParseCollection(t, encoded); parsed.String() != original.String() {
+				t.Errorf("%s with %+v did not survive the round trip.\nExpected %s\nFound %s", file, option, original, parsed)
+			}
+		}
+
+		if file == compressedFile {
+			// compressing changes the values, but not the points
+			parsed := sgftest.ParseCollection(t, encode(t, original, compressedOptions))
+			if len(parsed.GameTrees) != len(original.GameTrees) {
+				t.Fatalf("Expected %d trees, found %d", len(original.GameTrees), len(parsed.GameTrees))
+			}
+			for i, tree := range original.GameTrees {
+				expected, found := pointCount(tree), pointCount(parsed.GameTrees[i])
+				if expected != found {
+					t.Errorf("Tree %d: expected %d points, found %d", i, expected, found)
+				}
+			}
+		}
+	}
+}
+
+// pointCount counts the expanded points of all point lists in the tree
+func pointCount(tree *structures.GameTree) int {
+	count := 0
+	for _, line := range tree.Lines() {
+		for _, node := range line {
+			for _, prop := range node.Properties {
+				if points, err := prop.AsPoints(); err == nil {
+					count += len(points)
+				}
+			}
+		}
+	}
+	return count
+}
+
+func TestRawRoundTrip(t *testing.T) {
+	raw := "(;FF[4]C[first line\\\n  continued\ttab\n\nnext \\: paragraph]N[some\nname])"
+
+	for _, mode := range []parser.ValueMode{parser.NormalizedValues, parser.RawValues} {
+		collection, _, err := parser.ParseCollectionWithOptions(bufio.NewReader(strings.NewReader(raw)), parser.Options{Values: mode})
+		if err != nil {
+			t.Fatalf("Failed to parse: %s", err.Error())
+		}
+
+		// unchanged values keep their formatting
+		if encoded := encode(t, collection, writer.Options{Compact: true}); encoded != raw {
+			t.Errorf("Mode %d: expected %q, found %q", mode, raw, encoded)
+		}
+
+		// changed values are escaped
+		collection.GameTrees[0].Sequence.Nodes[0].Properties[2].Values[0] = "new]name"
+		if encoded := encode(t, collection, writer.Options{Compact: true}); !strings.HasSuffix(encoded, "N[new\\]name])") {
+			t.Errorf("Mode %d: expected the changed value to be escaped, found %q", mode, encoded)
+		}
+	}
+}
+
+func TestCharset(t *testing.T) {
+	raw := "(;FF[4]CA[ISO-8859-1]C[caf\xe9 \xbd])"
+
+	decoder, err := parser.Open(strings.NewReader(raw), parser.Options{Recovery: parser.FailFast})
+	if err != nil {
+		t.Fatalf("Failed to open: %s", err.Error())
+	}
+	gTree, err := decoder.Next()
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err.Error())
+	}
+	collection := &structures.Collection{GameTrees: []*structures.GameTree{gTree}, Charset: decoder.Charset()}
+
+	if encoded := encode(t, collection, writer.Options{Compact: true}); encoded != raw {
+		t.Errorf("Expected the original encoding %q, found %q", raw, encoded)
+	}
+	if encoded := encode(t, collection, writer.Options{Compact: true, Charset: "UTF-8"}); encoded != "(;FF[4]CA[ISO-8859-1]C[café ½])" {
+		t.Errorf("Expected UTF-8 output, found %q", encoded)
+	}
+	if encoded := encode(t, collection, writer.Options{Compact: true, Charset: "ASCII"}); encoded != "(;FF[4]CA[ISO-8859-1]C[caf? ?])" {
+		t.Errorf("Expected ASCII output, found %q", encoded)
+	}
+}